@@ -44,6 +44,8 @@ func main() {
 	cron.Start()
 	cron.FetchLoad()
 	cron.CheckLoad()
+	cron.ShareLoad()
+	cron.WatchLoad()
 
 	node.InitNodePool(op.GetSettingInt(setting.NODE_POOL_SIZE))
 
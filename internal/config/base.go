@@ -7,10 +7,12 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"testing"
 
 	"github.com/bestruirui/bestsub/internal/models/config"
 	"github.com/bestruirui/bestsub/internal/utils"
@@ -27,7 +29,10 @@ func init() {
 	defaultConfigPath := filepath.Join(execDir, "config.json")
 
 	configPath := flag.String("c", defaultConfigPath, "config file path")
-	flag.Parse()
+	// 测试时的参数由 testing 解析，使用测试程序目录下的默认配置
+	if !testing.Testing() {
+		flag.Parse()
+	}
 	if *configPath == "" {
 		*configPath = defaultConfigPath
 	}
@@ -138,6 +143,20 @@ func loadFromEnv(config *config.Base) {
 	if sessionFile := os.Getenv("BESTSUB_SESSION_FILE"); sessionFile != "" {
 		config.Session.AuthPath = sessionFile
 	}
+	if proxies := os.Getenv("BESTSUB_TRUSTED_PROXIES"); proxies != "" {
+		config.Server.TrustedProxies = nil
+		for _, proxy := range strings.Split(proxies, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				config.Server.TrustedProxies = append(config.Server.TrustedProxies, proxy)
+			}
+		}
+	}
+	if scUrl := os.Getenv("BESTSUB_SUBCONVERTER_URL"); scUrl != "" {
+		config.SubConverter.Url = scUrl
+	}
+	if scCallback := os.Getenv("BESTSUB_SUBCONVERTER_CALLBACK"); scCallback != "" {
+		config.SubConverter.Callback = scCallback
+	}
 }
 
 func parsePort(portStr string) (int, error) {
@@ -196,6 +215,10 @@ func validateConfig(config *config.Base) error {
 		return fmt.Errorf("会话配置验证失败: %v", err)
 	}
 
+	if err := validateSubConverterConfig(&config.SubConverter); err != nil {
+		return fmt.Errorf("subconverter配置验证失败: %v", err)
+	}
+
 	return nil
 }
 
@@ -293,3 +316,17 @@ func validateSessionConfig(config *config.SessionConfig) error {
 
 	return nil
 }
+
+func validateSubConverterConfig(config *config.SubConverterConfig) error {
+	for _, item := range []*string{&config.Url, &config.Callback} {
+		if *item == "" {
+			continue
+		}
+		u, err := url.Parse(*item)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("无效的地址: %s，需要 http 或 https 地址", *item)
+		}
+		*item = strings.TrimRight(*item, "/")
+	}
+	return nil
+}
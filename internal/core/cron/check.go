@@ -3,6 +3,7 @@ package cron
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
 	checkModel "github.com/bestruirui/bestsub/internal/models/check"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/modules/notify"
 	"github.com/bestruirui/bestsub/internal/utils/generic"
 	"github.com/bestruirui/bestsub/internal/utils/log"
 	"github.com/robfig/cron/v3"
@@ -55,6 +58,7 @@ func CheckAdd(data *checkModel.Data) error {
 			checker, err := check.Get(taskConfig.Type, data.Config)
 			if err != nil {
 				log.Errorf("failed to get execer: %v", err)
+				notifyCheck(data, &taskConfig, checkModel.Result{Msg: err.Error()}, true)
 				return
 			}
 			log.Infof("%s task %d start", taskConfig.Type, data.ID)
@@ -65,6 +69,11 @@ func CheckAdd(data *checkModel.Data) error {
 				result = checker.Run(ctx, logger, taskConfig.SubID)
 			}
 			log.Infof("%s task %d end", taskConfig.Type, data.ID)
+			failed := errors.Is(ctx.Err(), context.DeadlineExceeded)
+			if failed {
+				result.Msg = fmt.Sprintf("timeout after %d minutes", taskConfig.Timeout)
+			}
+			notifyCheck(data, &taskConfig, result, failed)
 			op.UpdateCheckResult(data.ID, result)
 			node.RefreshInfo()
 		},
@@ -75,6 +84,37 @@ func CheckAdd(data *checkModel.Data) error {
 	}
 	return nil
 }
+
+// notifyCheck 发送检测任务事件，任务开启通知时按任务设置发送结果，需要在保存本次结果前调用以读取上一次的结果
+func notifyCheck(data *checkModel.Data, task *checkModel.Task, result checkModel.Result, failed bool) {
+	notify.Emit(notifyModel.CheckEvent{ID: data.ID, Name: data.Name, Kind: task.Type, Msg: result.Msg, Duration: result.Duration, Failed: failed})
+	if !task.Notify {
+		return
+	}
+	taskResult := notifyModel.TaskResult{
+		ID:       data.ID,
+		Name:     data.Name,
+		Task:     "check",
+		Type:     task.Type,
+		Msg:      result.Msg,
+		Duration: result.Duration,
+		Failed:   failed,
+		Extra:    extraMap(result.Extra),
+	}
+	if prev, err := op.GetCheckByID(data.ID); err == nil && prev.Result != "" {
+		var prevResult checkModel.Result
+		if json.Unmarshal([]byte(prev.Result), &prevResult) == nil {
+			taskResult.Prev, taskResult.PrevMsg = extraMap(prevResult.Extra), prevResult.Msg
+		}
+	}
+	go notify.SendTaskNotify(task.NotifyChannel, task.NotifyMode, task.NotifyChange, &taskResult)
+}
+
+func extraMap(extra any) map[string]any {
+	m, _ := extra.(map[string]any)
+	return m
+}
+
 func CheckUpdate(data *checkModel.Data) error {
 	CheckRemove(data.ID)
 	CheckAdd(data)
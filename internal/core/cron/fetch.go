@@ -7,8 +7,11 @@ import (
 	"time"
 
 	"github.com/bestruirui/bestsub/internal/core/fetch"
+	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
 	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/modules/notify"
 	"github.com/bestruirui/bestsub/internal/utils/generic"
 	"github.com/bestruirui/bestsub/internal/utils/log"
 	"github.com/robfig/cron/v3"
@@ -38,17 +41,29 @@ func FetchAdd(data *subModel.Data) error {
 				cancel()
 				fetchRunning.Delete(data.ID)
 			}()
-			result := fetch.Do(ctx, data.ID, data.Config)
+			prev, err := op.GetSubByID(ctx, data.ID)
+			if err != nil {
+				log.Warnf("failed to get sub by id: %v", err)
+				return
+			}
+			result, history := fetch.Do(ctx, data.ID, data.Config)
 			op.UpdateSubResult(ctx, data.ID, result)
+			if err := op.CreateSubHistory(context.Background(), &history); err != nil {
+				log.Warnf("failed to save fetch history: %v", err)
+			}
 			sub, err := op.GetSubByID(ctx, data.ID)
 			if err != nil {
 				log.Warnf("failed to get sub by id: %v", err)
 				return
 			}
+			notifyFetch(prev, sub, result)
 			if !sub.Enable {
 				FetchDisable(data.ID)
 				log.Infof("fetch task %d auto disable", data.ID)
+				return
 			}
+			// 新节点测试合并后节点池数据才是最新的
+			node.AfterMerge(func() { evaluateQuality(data.ID) })
 		},
 		cronExpr: data.CronExpr,
 	})
@@ -58,6 +73,54 @@ func FetchAdd(data *subModel.Data) error {
 	return nil
 }
 
+// evaluateQuality 评估订阅质量，因质量过低被禁用时停止获取任务
+func evaluateQuality(id uint16) {
+	sub, err := op.GetSubByID(context.Background(), id)
+	if err != nil {
+		log.Warnf("failed to get sub by id: %v", err)
+		return
+	}
+	if !sub.Enable {
+		return
+	}
+	fetch.EvaluateQuality(context.Background(), sub)
+	if !sub.Enable {
+		FetchDisable(id)
+		log.Infof("fetch task %d auto disable", id)
+	}
+}
+
+// notifyFetch 获取失败以及因连续获取不到节点被自动禁用时发送事件，订阅开启通知时按订阅设置发送本次结果
+func notifyFetch(prev *subModel.Data, sub *subModel.Data, result subModel.Result) {
+	var total, prevResult subModel.Result
+	json.Unmarshal([]byte(sub.Result), &total)
+	json.Unmarshal([]byte(prev.Result), &prevResult)
+	if result.Fail > 0 {
+		notify.Emit(notifyModel.FetchFailedEvent{ID: sub.ID, Name: sub.Name, Msg: result.Msg, Fail: total.Fail})
+	}
+	if prev.Enable && !sub.Enable {
+		notify.Emit(notifyModel.SubDisabledEvent{ID: sub.ID, Name: sub.Name, NodeNullCount: total.NodeNullCount})
+	}
+	var config subModel.Config
+	if err := json.Unmarshal([]byte(sub.Config), &config); err != nil || !config.Notify {
+		return
+	}
+	taskResult := notifyModel.TaskResult{
+		ID:       sub.ID,
+		Name:     sub.Name,
+		Task:     "fetch",
+		Msg:      result.Msg,
+		Duration: int64(result.Duration),
+		Failed:   result.Fail > 0,
+		Extra:    map[string]any{"raw_count": result.RawCount},
+		PrevMsg:  prevResult.Msg,
+	}
+	if !prevResult.LastRun.IsZero() {
+		taskResult.Prev = map[string]any{"raw_count": prevResult.RawCount}
+	}
+	go notify.SendTaskNotify(config.NotifyChannel, config.NotifyMode, config.NotifyChange, &taskResult)
+}
+
 func FetchRun(subID uint16) subModel.Result {
 	if ft, ok := fetchFunc.Load(subID); ok {
 		ft.fn()
@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/modules/notify"
+	"github.com/bestruirui/bestsub/internal/modules/share"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// shareExpireNotice 分享过期前多久发送通知
+const shareExpireNotice = 24 * time.Hour
+
+// shareExpireNotified 已通知过的分享及其过期时间，修改过期时间后会重新通知
+var shareExpireNotified = generic.MapOf[uint16, uint64]{}
+
+// ShareLoad 定时检查需要自动更换token以及即将过期的分享链接
+func ShareLoad() {
+	if _, err := scheduler.AddFunc("@every 1m", rotateShareTokens); err != nil {
+		log.Errorf("failed to add share token rotation: %v", err)
+	}
+	if _, err := scheduler.AddFunc("@every 10m", notifyExpiringShares); err != nil {
+		log.Errorf("failed to add share expiration check: %v", err)
+	}
+}
+
+func notifyExpiringShares() {
+	shares, err := op.GetShareList(context.Background())
+	if err != nil {
+		log.Errorf("failed to load share list: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, s := range shares {
+		if !s.Enable || s.Expires == 0 {
+			continue
+		}
+		expires := time.Unix(int64(s.Expires), 0)
+		left := expires.Sub(now)
+		if left <= 0 || left > shareExpireNotice {
+			continue
+		}
+		if notified, ok := shareExpireNotified.Load(s.ID); ok && notified == s.Expires {
+			continue
+		}
+		shareExpireNotified.Store(s.ID, s.Expires)
+		notify.Emit(notifyModel.ShareExpiringEvent{
+			ID:      s.ID,
+			Name:    s.Name,
+			Expires: expires.Format(time.DateTime),
+			Left:    left.Round(time.Minute).String(),
+		})
+	}
+}
+
+func rotateShareTokens() {
+	ctx := context.Background()
+	shares, err := op.GetShareList(ctx)
+	if err != nil {
+		log.Errorf("failed to load share list: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, s := range shares {
+		access := s.AccessConfig()
+		if access.RotateInterval == 0 {
+			continue
+		}
+		next := time.Unix(int64(s.TokenRotatedAt), 0).Add(time.Duration(access.RotateInterval) * time.Hour)
+		if now.Before(next) {
+			continue
+		}
+		if _, err := op.RotateShareToken(ctx, s.ID, time.Duration(access.RotateGrace)*time.Minute); err != nil {
+			log.Errorf("failed to rotate share %d token: %v", s.ID, err)
+			continue
+		}
+		share.ResetAccess(s.ID)
+		log.Infof("share %s token rotated", s.Name)
+	}
+}
@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/core/update"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/modules/notify"
+	"github.com/bestruirui/bestsub/internal/modules/subcer"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/bestruirui/bestsub/internal/utils/info"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+var (
+	poolLow        atomic.Bool
+	updateNotified = generic.MapOf[string, string]{}
+)
+
+// WatchLoad 定时检查节点池数量与新版本
+func WatchLoad() {
+	if _, err := scheduler.AddFunc("@every 5m", checkPoolSize); err != nil {
+		log.Errorf("failed to add pool size check: %v", err)
+	}
+	if _, err := scheduler.AddFunc("@every 12h", checkUpdate); err != nil {
+		log.Errorf("failed to add update check: %v", err)
+	}
+}
+
+// checkPoolSize 节点池数量低于阈值时通知一次，恢复后再次低于阈值时重新通知
+func checkPoolSize() {
+	threshold := op.GetSettingInt(setting.NODE_POOL_MIN)
+	if threshold <= 0 {
+		return
+	}
+	count := node.Count()
+	if count >= threshold {
+		poolLow.Store(false)
+		return
+	}
+	if poolLow.Swap(true) {
+		return
+	}
+	notify.Emit(notifyModel.PoolLowEvent{Count: count, Threshold: threshold})
+}
+
+// checkUpdate 每个新版本只通知一次
+func checkUpdate() {
+	if info.Version != "dev" {
+		if latest, err := update.GetLatestBestsubInfo(); err != nil {
+			log.Debugf("failed to check bestsub update: %v", err)
+		} else {
+			notifyUpdate("bestsub", info.Version, latest.TagName)
+		}
+	}
+	if subcer.Remote() {
+		return
+	}
+	if current := subcer.Status().Version; current != "" {
+		if latest, err := update.GetLatestSubconverterInfo(); err != nil {
+			log.Debugf("failed to check subconverter update: %v", err)
+		} else {
+			notifyUpdate("subconverter", current, latest.TagName)
+		}
+	}
+}
+
+func notifyUpdate(component, current, latest string) {
+	if latest == "" || strings.TrimPrefix(latest, "v") == strings.TrimPrefix(current, "v") {
+		return
+	}
+	if notified, ok := updateNotified.Load(component); ok && notified == latest {
+		return
+	}
+	updateNotified.Store(component, latest)
+	notify.Emit(notifyModel.UpdateEvent{Component: component, Current: current, Latest: latest})
+}
@@ -8,29 +8,33 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"slices"
-	"strings"
+	"strconv"
 	"time"
 
-	"github.com/bestruirui/bestsub/internal/config"
 	"github.com/bestruirui/bestsub/internal/core/mihomo"
 	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
 	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
 	"github.com/bestruirui/bestsub/internal/models/setting"
 	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/modules/subcer"
+	"github.com/bestruirui/bestsub/internal/utils/country"
 	"github.com/bestruirui/bestsub/internal/utils/log"
 	"gopkg.in/yaml.v3"
 )
 
-func Do(ctx context.Context, subID uint16, config string) subModel.Result {
+func Do(ctx context.Context, subID uint16, config string) (subModel.Result, subModel.History) {
 	startTime := time.Now()
 	retry := 0
+	history := subModel.History{
+		SubID:     subID,
+		FetchedAt: startTime,
+	}
 
 	var subConfig subModel.Config
 	if err := json.Unmarshal([]byte(config), &subConfig); err != nil {
 		log.Warnf("fetch task %d failed: %v", subID, err)
-		return createFailureResult(err.Error(), startTime)
+		return createFailureResult(err.Error(), startTime), createFailureHistory(history, err.Error(), startTime)
 	}
 
 	log.Debugf("fetch task %d started", subID)
@@ -38,10 +42,25 @@ func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 	client := mihomo.Default(false)
 	if client == nil {
 		log.Warnf("fetch task %d failed: proxy config error", subID)
-		return createFailureResult("proxy config error", startTime)
+		return createFailureResult("proxy config error", startTime), createFailureHistory(history, "proxy config error", startTime)
 	}
 	defer client.Release()
+	filter, err := newNodeFilter(&subConfig)
+	if err != nil {
+		log.Warnf("fetch task %d failed: %v", subID, err)
+		return createFailureResult(err.Error(), startTime), createFailureHistory(history, err.Error(), startTime)
+	}
+	lastErr := "fetch task failed"
 	subUrl := genSubConverterUrl(subConfig.Url, subConfig.Proxy)
+	if subConfig.PoolProxy {
+		if content, err := fetchViaPool(ctx, subID, &subConfig); err != nil {
+			log.Warnf("fetch task %d via pool failed, fallback: %v", subID, err)
+		} else {
+			rawUrl, release := storeRawContent(content)
+			defer release()
+			subUrl = genSubConverterUrl(rawUrl, false)
+		}
+	}
 	for retry < 3 {
 		time.Sleep(time.Duration(retry) * time.Second)
 		retry++
@@ -50,28 +69,33 @@ func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 		req, err := http.NewRequestWithContext(ctx, "GET", subUrl, nil)
 		if err != nil {
 			log.Warnf("fetch task %d failed: %v", subID, err)
+			lastErr = err.Error()
 			continue
 		}
 
 		resp, err := client.Do(req)
 		if err != nil {
 			log.Warnf("fetch task %d failed: %v", subID, err)
+			lastErr = err.Error()
 			continue
 		}
 		defer resp.Body.Close()
+		history.StatusCode = resp.StatusCode
+		if resp.StatusCode >= http.StatusBadRequest {
+			lastErr = fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+			log.Warnf("fetch task %d failed: %s", subID, lastErr)
+			continue
+		}
 
 		content, err := io.ReadAll(resp.Body)
 		if err != nil {
 			log.Warnf("fetch task %d failed: %v", subID, err)
+			lastErr = err.Error()
 			continue
 		}
 
-		globalProtocolFilterEnable := op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_ENABLE)
-		globalProtocolFilterMode := op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_MODE)
-		globalProtocolFilter := strings.Split(op.GetSettingStr(setting.NODE_PROTOCOL_FILTER), ",")
-
 		var nodes []nodeModel.Base
-		var unique nodeModel.UniqueKey
+		var parsed fetchNode
 		lines := bytes.Split(content, []byte("\n"))
 		lines = lines[1:]
 		for _, line := range lines {
@@ -79,52 +103,52 @@ func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 				continue
 			}
 			line = line[4:]
-			if err := yaml.Unmarshal(line, &unique); err != nil {
+			parsed = fetchNode{}
+			if err := yaml.Unmarshal(line, &parsed); err != nil {
 				continue
 			}
-			if subConfig.ProtocolFilterEnable {
-				if subConfig.ProtocolFilterMode {
-					if !slices.Contains(subConfig.ProtocolFilter, unique.Type) {
-						continue
-					}
-				} else {
-					if slices.Contains(subConfig.ProtocolFilter, unique.Type) {
-						continue
-					}
-				}
-			} else {
-				if globalProtocolFilterEnable {
-					if globalProtocolFilterMode {
-						if !slices.Contains(globalProtocolFilter, unique.Type) {
-							log.Debugf("全局协议过滤启用,协议包含模式 丢弃协议: %v", unique.Type)
-							continue
-						}
-					} else {
-						if slices.Contains(globalProtocolFilter, unique.Type) {
-							log.Debugf("全局协议过滤启用,协议排除模式 丢弃协议: %v", unique.Type)
-							continue
-						}
-					}
-				}
+			history.RawCount++
+			unique := parsed.UniqueKey
+			if !filter.match(parsed.Name, unique.Server, unique.Type) {
+				continue
 			}
+			key := unique.Gen()
 			nodes = append(nodes, nodeModel.Base{
 				Raw:       line,
 				SubId:     subID,
-				UniqueKey: unique.Gen(),
+				UniqueKey: key,
+				Name:      parsed.Name,
+				Region:    country.Hint(parsed.Name),
+			})
+			history.Snapshot = append(history.Snapshot, subModel.HistoryNode{
+				Key:  strconv.FormatUint(key, 16),
+				Name: parsed.Name,
 			})
 		}
 
 		count := len(nodes)
 
-		node.Add(&nodes)
+		admitted := node.Add(&nodes)
 
 		log.Debugf("fetch task %d completed, node count: %d,  duration: %dms",
 			subID, count, uint16(time.Since(startTime).Milliseconds()))
 
-		return createSuccessResult(uint32(count), startTime, count == 0)
+		history.FilteredCount = uint32(count)
+		history.AdmittedCount = uint32(admitted)
+		history.Duration = uint16(time.Since(startTime).Milliseconds())
+		return createSuccessResult(uint32(count), startTime, count == 0), history
 	}
-	return createFailureResult("fetch task failed", startTime)
+	if lastErr == "" {
+		lastErr = "fetch task failed"
+	}
+	return createFailureResult(lastErr, startTime), createFailureHistory(history, lastErr, startTime)
+}
+
+type fetchNode struct {
+	nodeModel.UniqueKey `yaml:",inline"`
+	Name                string `yaml:"name"`
 }
+
 func createFailureResult(msg string, startTime time.Time) subModel.Result {
 	return subModel.Result{
 		Success:  0,
@@ -135,6 +159,12 @@ func createFailureResult(msg string, startTime time.Time) subModel.Result {
 	}
 }
 
+func createFailureHistory(history subModel.History, msg string, startTime time.Time) subModel.History {
+	history.Error = msg
+	history.Duration = uint16(time.Since(startTime).Milliseconds())
+	return history
+}
+
 func createSuccessResult(count uint32, startTime time.Time, nodeNull bool) subModel.Result {
 	nodeNullCount := uint16(0)
 	if nodeNull {
@@ -152,13 +182,11 @@ func createSuccessResult(count uint32, startTime time.Time, nodeNull bool) subMo
 }
 func genSubConverterUrl(subUrl string, enableProxy bool) string {
 	subUrl = url.QueryEscape(subUrl)
-	cfg := config.Base()
-	scHost := cfg.SubConverter.Host
-	scPort := cfg.SubConverter.Port
+	baseUrl := subcer.GetBaseUrl()
 	if enableProxy {
 		proxy := op.GetSettingStr(setting.PROXY_URL)
 		proxy = url.QueryEscape(proxy)
-		return fmt.Sprintf("http://%s:%d/sub?target=clash&list=true&url=%s&sub_proxy=%s", scHost, scPort, subUrl, proxy)
+		return fmt.Sprintf("%s/sub?target=clash&list=true&url=%s&sub_proxy=%s", baseUrl, subUrl, proxy)
 	}
-	return fmt.Sprintf("http://%s:%d/sub?target=clash&list=true&url=%s", scHost, scPort, subUrl)
+	return fmt.Sprintf("%s/sub?target=clash&list=true&url=%s", baseUrl, subUrl)
 }
@@ -0,0 +1,108 @@
+package fetch
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// junkName 订阅商常见的信息类伪节点名称
+var junkName = regexp.MustCompile(`(?i)剩余|流量|到期|过期|官网|网址|官方|客服|套餐|重置|续费|公告|群组|频道|邮箱|QQ群|TG群|t\.me|expire|traffic|remaining|website`)
+
+// junkServer 不可能连通的节点地址
+var junkServer = []string{"", "127.0.0.1", "0.0.0.0", "localhost", "::1"}
+
+type nodeFilter struct {
+	protocolEnable bool
+	protocolMode   bool
+	protocol       []string
+	protocolGlobal bool
+
+	junk bool
+
+	name   []regexpRule
+	server []regexpRule
+}
+
+type regexpRule struct {
+	re      *regexp.Regexp
+	include bool
+}
+
+// newNodeFilter 根据订阅配置与全局设置构建节点过滤器
+func newNodeFilter(subConfig *subModel.Config) (*nodeFilter, error) {
+	f := &nodeFilter{
+		junk: op.GetSettingBool(setting.NODE_JUNK_FILTER),
+	}
+	if subConfig.ProtocolFilterEnable {
+		f.protocolEnable = true
+		f.protocolMode = subConfig.ProtocolFilterMode
+		f.protocol = subConfig.ProtocolFilter
+	} else if op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_ENABLE) {
+		f.protocolEnable = true
+		f.protocolGlobal = true
+		f.protocolMode = op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_MODE)
+		f.protocol = strings.Split(op.GetSettingStr(setting.NODE_PROTOCOL_FILTER), ",")
+	}
+
+	var err error
+	if f.name, err = appendRules(f.name,
+		op.GetSettingStr(setting.NODE_NAME_INCLUDE), op.GetSettingStr(setting.NODE_NAME_EXCLUDE),
+		subConfig.NameInclude, subConfig.NameExclude); err != nil {
+		return nil, err
+	}
+	if f.server, err = appendRules(f.server,
+		op.GetSettingStr(setting.NODE_SERVER_INCLUDE), op.GetSettingStr(setting.NODE_SERVER_EXCLUDE),
+		subConfig.ServerInclude, subConfig.ServerExclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// appendRules 按 包含,排除,包含,排除... 的顺序编译正则
+func appendRules(rules []regexpRule, exprs ...string) ([]regexpRule, error) {
+	for i, expr := range exprs {
+		if expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+		rules = append(rules, regexpRule{re: re, include: i%2 == 0})
+	}
+	return rules, nil
+}
+
+// match 判断节点是否通过过滤
+func (f *nodeFilter) match(name, server, protocol string) bool {
+	if f.protocolEnable && slices.Contains(f.protocol, protocol) != f.protocolMode {
+		if f.protocolGlobal {
+			log.Debugf("全局协议过滤启用 丢弃协议: %v", protocol)
+		}
+		return false
+	}
+	if f.junk && (junkName.MatchString(name) || slices.Contains(junkServer, server)) {
+		log.Debugf("丢弃无效节点: %s", name)
+		return false
+	}
+	for _, r := range f.name {
+		if r.re.MatchString(name) != r.include {
+			log.Debugf("名称过滤 丢弃节点: %s", name)
+			return false
+		}
+	}
+	for _, r := range f.server {
+		if r.re.MatchString(server) != r.include {
+			log.Debugf("地址过滤 丢弃节点: %s", name)
+			return false
+		}
+	}
+	return true
+}
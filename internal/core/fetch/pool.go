@@ -0,0 +1,104 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/core/mihomo"
+	"github.com/bestruirui/bestsub/internal/core/node"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/modules/subcer"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// poolUserAgent 通过节点池获取订阅时使用的UA，使订阅商返回 subconverter 可解析的内容
+const poolUserAgent = "clash.meta"
+
+// rawContent 通过节点池获取到的订阅原始内容，供 subconverter 凭一次性token读取
+var rawContent = generic.MapOf[string, []byte]{}
+
+// GetRawContent 根据一次性token获取订阅原始内容
+func GetRawContent(token string) ([]byte, bool) {
+	return rawContent.Load(token)
+}
+
+// storeRawContent 保存原始内容并返回 subconverter 可访问的地址与清理函数
+func storeRawContent(content []byte) (string, func()) {
+	token := uuid.NewString()
+	rawContent.Store(token, content)
+	return fmt.Sprintf("%s/api/v1/sub/raw/%s", subcer.CallbackUrl(), token), func() {
+		rawContent.Delete(token)
+	}
+}
+
+// fetchViaPool 依次尝试节点池中延迟最低的可用节点获取订阅原始内容
+func fetchViaPool(ctx context.Context, subID uint16, subConfig *subModel.Config) ([]byte, error) {
+	filter := subConfig.PoolFilter
+	filter.AliveStatus |= nodeModel.Alive
+	candidates := *node.GetByFilter(filter)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no available node in pool")
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Info.Delay.Average() < candidates[j].Info.Delay.Average()
+	})
+
+	retry := subConfig.PoolRetry
+	if retry <= 0 {
+		retry = 3
+	}
+	var lastErr error
+	for i := 0; i < len(candidates) && i < retry; i++ {
+		content, err := fetchViaNode(ctx, candidates[i].Base.Raw, subConfig)
+		if err != nil {
+			log.Debugf("fetch task %d via pool node failed: %v", subID, err)
+			lastErr = err
+			continue
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("all pool nodes failed: %w", lastErr)
+}
+
+func fetchViaNode(ctx context.Context, raw []byte, subConfig *subModel.Config) ([]byte, error) {
+	var proxy map[string]any
+	if err := yaml.Unmarshal(raw, &proxy); err != nil {
+		return nil, err
+	}
+	client := mihomo.Proxy(proxy)
+	if client == nil {
+		return nil, fmt.Errorf("parse proxy failed")
+	}
+	defer client.Release()
+	client.Timeout = time.Duration(subConfig.Timeout) * time.Second
+
+	req, err := http.NewRequestWithContext(ctx, "GET", subConfig.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", poolUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("empty content")
+	}
+	return content, nil
+}
@@ -0,0 +1,124 @@
+package fetch
+
+import (
+	"context"
+	"math"
+
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/modules/notify"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+const (
+	QualityActionNotify       = "notify"
+	QualityActionDeprioritize = "deprioritize"
+	QualityActionDisable      = "disable"
+)
+
+// qualityWindow 参与质量统计的最近获取次数
+const qualityWindow = 10
+
+// qualitySpeedRef 速度评分满分对应的下行速度 (KB/s)
+const qualitySpeedRef = 10240
+
+// lowQuality 已处理过的低质量订阅，避免重复通知
+var lowQuality = generic.MapOf[uint16, bool]{}
+
+// Quality 根据获取历史与节点池数据计算订阅质量
+func Quality(ctx context.Context, subID uint16) (subModel.Quality, error) {
+	quality := subModel.Quality{
+		SubID:       subID,
+		LowPriority: node.IsLowPriority(subID),
+	}
+	histories, err := op.GetSubHistory(ctx, subID, qualityWindow)
+	if err != nil {
+		return quality, err
+	}
+	var success uint32
+	for i, h := range histories {
+		if h.Error == "" {
+			success++
+		}
+		if i == 0 {
+			quality.FilteredCount = h.FilteredCount
+		}
+	}
+	quality.FetchCount = uint32(len(histories))
+	if quality.FetchCount > 0 {
+		quality.FetchSuccessRate = float64(success) / float64(quality.FetchCount)
+	}
+
+	info := node.GetSubInfo(subID)
+	quality.PoolCount = info.Count
+	quality.AliveCount = info.Alive
+	quality.SpeedDown = info.SpeedDown
+	quality.Delay = info.Delay
+	if quality.FilteredCount > 0 {
+		quality.AdmissionRate = math.Min(float64(info.Count)/float64(quality.FilteredCount), 1)
+	}
+	if info.Count > 0 {
+		quality.AliveRate = float64(info.Alive) / float64(info.Count)
+	}
+	if total := node.Count(); total > 0 {
+		quality.Contribution = float64(info.Count) / float64(total)
+	}
+
+	score := 40*quality.AdmissionRate +
+		30*quality.AliveRate +
+		20*quality.FetchSuccessRate +
+		10*math.Min(float64(quality.SpeedDown)/qualitySpeedRef, 1)
+	quality.Score = uint8(math.Round(score))
+	return quality, nil
+}
+
+// EvaluateQuality 按照设置的策略处理低质量订阅
+func EvaluateQuality(ctx context.Context, sub *subModel.Data) {
+	threshold := op.GetSettingInt(setting.SUB_QUALITY_MIN_SCORE)
+	if threshold <= 0 {
+		lowQuality.Delete(sub.ID)
+		node.SetLowPriority(sub.ID, false)
+		return
+	}
+	quality, err := Quality(ctx, sub.ID)
+	if err != nil {
+		log.Warnf("failed to evaluate sub %d quality: %v", sub.ID, err)
+		return
+	}
+	if quality.FetchCount < uint32(op.GetSettingInt(setting.SUB_QUALITY_MIN_FETCH)) {
+		return
+	}
+	action := op.GetSettingStr(setting.SUB_QUALITY_ACTION)
+	if int(quality.Score) >= threshold {
+		lowQuality.Delete(sub.ID)
+		node.SetLowPriority(sub.ID, false)
+		return
+	}
+	if _, ok := lowQuality.LoadOrStore(sub.ID, true); ok {
+		return
+	}
+
+	log.Infof("sub %d quality score %d below %d, action: %s", sub.ID, quality.Score, threshold, action)
+	switch action {
+	case QualityActionDeprioritize:
+		node.SetLowPriority(sub.ID, true)
+	case QualityActionDisable:
+		sub.Enable = false
+		if err := op.UpdateSub(ctx, sub); err != nil {
+			log.Warnf("failed to disable sub %d: %v", sub.ID, err)
+		}
+	}
+	notify.Emit(notifyModel.SubQualityEvent{
+		ID:            sub.ID,
+		Name:          sub.Name,
+		Score:         quality.Score,
+		Threshold:     threshold,
+		AdmissionRate: quality.AdmissionRate,
+		AliveRate:     quality.AliveRate,
+		Action:        action,
+	})
+}
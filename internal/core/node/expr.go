@@ -0,0 +1,317 @@
+package node
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 过滤表达式，例如:
+//
+//	protocol =~ "vless|trojan" && (tls || port == 443) && !(name =~ "测试") && tags == 家宽
+//
+// 字符串字段: name server protocol network country
+// 数值字段: port sub delay speed_up speed_down risk
+// 布尔字段: tls alive
+// 列表字段: tags (== 表示包含)
+type exprNode interface {
+	eval(env *nodeEnv) bool
+}
+
+type exprAnd struct{ left, right exprNode }
+type exprOr struct{ left, right exprNode }
+type exprNot struct{ x exprNode }
+type exprBool struct{ field string }
+type exprCompare struct {
+	field string
+	op    string
+	value string
+	num   float64
+	re    *regexp.Regexp
+}
+
+func (e *exprAnd) eval(env *nodeEnv) bool { return e.left.eval(env) && e.right.eval(env) }
+func (e *exprOr) eval(env *nodeEnv) bool  { return e.left.eval(env) || e.right.eval(env) }
+func (e *exprNot) eval(env *nodeEnv) bool { return !e.x.eval(env) }
+func (e *exprBool) eval(env *nodeEnv) bool {
+	return env.boolField(e.field)
+}
+
+func (e *exprCompare) eval(env *nodeEnv) bool {
+	switch fieldKind(e.field) {
+	case kindNumber:
+		v := env.numField(e.field)
+		switch e.op {
+		case "==":
+			return v == e.num
+		case "!=":
+			return v != e.num
+		case "<":
+			return v < e.num
+		case "<=":
+			return v <= e.num
+		case ">":
+			return v > e.num
+		case ">=":
+			return v >= e.num
+		}
+	case kindString:
+		v := env.strField(e.field)
+		switch e.op {
+		case "==":
+			return strings.EqualFold(v, e.value)
+		case "!=":
+			return !strings.EqualFold(v, e.value)
+		case "=~":
+			return e.re.MatchString(v)
+		case "!~":
+			return !e.re.MatchString(v)
+		}
+	case kindList:
+		v := env.listField(e.field)
+		switch e.op {
+		case "==":
+			return slices.Contains(v, e.value)
+		case "!=":
+			return !slices.Contains(v, e.value)
+		case "=~":
+			return slices.ContainsFunc(v, e.re.MatchString)
+		case "!~":
+			return !slices.ContainsFunc(v, e.re.MatchString)
+		}
+	case kindBool:
+		v := env.boolField(e.field)
+		b, _ := strconv.ParseBool(e.value)
+		if e.op == "==" {
+			return v == b
+		}
+		return v != b
+	}
+	return false
+}
+
+const (
+	kindUnknown = iota
+	kindString
+	kindNumber
+	kindBool
+	kindList
+)
+
+func fieldKind(field string) int {
+	switch field {
+	case "name", "server", "protocol", "network", "country":
+		return kindString
+	case "port", "sub", "delay", "speed_up", "speed_down", "risk":
+		return kindNumber
+	case "tls", "alive":
+		return kindBool
+	case "tags":
+		return kindList
+	}
+	return kindUnknown
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseExpr 解析过滤表达式
+func parseExpr(s string) (exprNode, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", p.tokens[p.pos])
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	switch t := p.next(); t {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "!":
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNot{x}, nil
+	case "(":
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return x, nil
+	default:
+		kind := fieldKind(t)
+		if kind == kindUnknown {
+			return nil, fmt.Errorf("unknown field %q", t)
+		}
+		op := p.peek()
+		switch op {
+		case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+		default:
+			if kind != kindBool {
+				return nil, fmt.Errorf("field %q requires a comparison", t)
+			}
+			return &exprBool{field: t}, nil
+		}
+		p.next()
+		value := p.next()
+		if value == "" {
+			return nil, fmt.Errorf("missing value after %s", op)
+		}
+		value = unquote(value)
+		cmp := &exprCompare{field: t, op: op, value: value}
+		switch kind {
+		case kindNumber:
+			if op == "=~" || op == "!~" {
+				return nil, fmt.Errorf("operator %s not supported for %q", op, t)
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %q requires a number", t)
+			}
+			cmp.num = n
+		case kindString, kindList:
+			switch op {
+			case "=~", "!~":
+				re, err := regexp.Compile(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid regex %q: %w", value, err)
+				}
+				cmp.re = re
+			case "==", "!=":
+			default:
+				return nil, fmt.Errorf("operator %s not supported for %q", op, t)
+			}
+		case kindBool:
+			if op != "==" && op != "!=" {
+				return nil, fmt.Errorf("operator %s not supported for %q", op, t)
+			}
+			if _, err := strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("field %q requires true or false", t)
+			}
+		}
+		return cmp, nil
+	}
+}
+
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		case strings.ContainsRune("()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case strings.ContainsRune("&|=!<>", r):
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "&&", "||", "==", "!=", "<=", ">=", "=~", "!~":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if r == '!' || r == '<' || r == '>' {
+				tokens = append(tokens, string(r))
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q", r)
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()&|=!<>\"'", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		body := s[1 : len(s)-1]
+		return strings.NewReplacer(`\"`, `"`, `\'`, `'`, `\\`, `\`).Replace(body)
+	}
+	return s
+}
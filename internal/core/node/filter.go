@@ -0,0 +1,210 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+// nodeAttr 从原始配置中解析出的节点属性
+type nodeAttr struct {
+	Name    string         `yaml:"name"`
+	Server  string         `yaml:"server"`
+	Type    string         `yaml:"type"`
+	Port    string         `yaml:"port"`
+	TLS     bool           `yaml:"tls"`
+	Network string         `yaml:"network"`
+	Reality map[string]any `yaml:"reality-opts"`
+}
+
+// tlsProtocol 固定使用 TLS 的协议
+var tlsProtocol = []string{"trojan", "hysteria", "hysteria2", "tuic", "anytls"}
+
+type portRange struct{ from, to uint16 }
+
+// compiledFilter 预编译的扩展过滤条件
+type compiledFilter struct {
+	filter      nodeModel.Filter
+	nameInclude *regexp.Regexp
+	nameExclude *regexp.Regexp
+	ports       []portRange
+	expr        exprNode
+	tags        map[uint16][]string
+}
+
+// nodeEnv 单个节点的求值环境，属性按需解析
+type nodeEnv struct {
+	node   *nodeModel.Data
+	filter *compiledFilter
+	attr   *nodeAttr
+}
+
+// ValidateFilter 检查过滤条件中的正则、端口范围与表达式
+func ValidateFilter(filter nodeModel.Filter) error {
+	_, err := compileFilter(filter)
+	return err
+}
+
+func compileFilter(filter nodeModel.Filter) (*compiledFilter, error) {
+	c := &compiledFilter{filter: filter, tags: make(map[uint16][]string)}
+	var err error
+	if filter.NameInclude != "" {
+		if c.nameInclude, err = regexp.Compile(filter.NameInclude); err != nil {
+			return nil, fmt.Errorf("invalid name_include: %w", err)
+		}
+	}
+	if filter.NameExclude != "" {
+		if c.nameExclude, err = regexp.Compile(filter.NameExclude); err != nil {
+			return nil, fmt.Errorf("invalid name_exclude: %w", err)
+		}
+	}
+	if c.ports, err = parsePorts(filter.Port); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(filter.Expr) != "" {
+		if c.expr, err = parseExpr(filter.Expr); err != nil {
+			return nil, fmt.Errorf("invalid expr: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// parsePorts 解析 "443,8000-9000" 格式的端口范围
+func parsePorts(s string) ([]portRange, error) {
+	var ranges []portRange
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, found := strings.Cut(part, "-")
+		start, err := strconv.ParseUint(strings.TrimSpace(from), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", part)
+		}
+		end := start
+		if found {
+			if end, err = strconv.ParseUint(strings.TrimSpace(to), 10, 16); err != nil || end < start {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		ranges = append(ranges, portRange{uint16(start), uint16(end)})
+	}
+	return ranges, nil
+}
+
+// match 检查扩展过滤条件
+func (c *compiledFilter) match(n *nodeModel.Data) bool {
+	f := &c.filter
+	env := &nodeEnv{node: n, filter: c}
+	if len(f.Protocol) > 0 && slices.Contains(f.Protocol, env.strField("protocol")) == f.ProtocolExclude {
+		return false
+	}
+	if c.nameInclude != nil && !c.nameInclude.MatchString(env.strField("name")) {
+		return false
+	}
+	if c.nameExclude != nil && c.nameExclude.MatchString(env.strField("name")) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		tags := env.listField("tags")
+		hit := slices.ContainsFunc(f.Tags, func(t string) bool { return slices.Contains(tags, t) })
+		if hit == f.TagsExclude {
+			return false
+		}
+	}
+	if len(c.ports) > 0 {
+		port := uint16(env.numField("port"))
+		if !slices.ContainsFunc(c.ports, func(r portRange) bool { return port >= r.from && port <= r.to }) {
+			return false
+		}
+	}
+	if f.TLS != nil && env.boolField("tls") != *f.TLS {
+		return false
+	}
+	if len(f.Network) > 0 && !slices.Contains(f.Network, env.strField("network")) {
+		return false
+	}
+	if c.expr != nil && !c.expr.eval(env) {
+		return false
+	}
+	return true
+}
+
+func (e *nodeEnv) attrs() *nodeAttr {
+	if e.attr == nil {
+		e.attr = &nodeAttr{}
+		yaml.Unmarshal(e.node.Base.Raw, e.attr)
+		if e.attr.Network == "" {
+			e.attr.Network = "tcp"
+		}
+	}
+	return e.attr
+}
+
+func (e *nodeEnv) strField(field string) string {
+	switch field {
+	case "name":
+		return e.attrs().Name
+	case "server":
+		return e.attrs().Server
+	case "protocol":
+		return e.attrs().Type
+	case "network":
+		return e.attrs().Network
+	case "country":
+		return e.node.Info.Country
+	}
+	return ""
+}
+
+func (e *nodeEnv) numField(field string) float64 {
+	switch field {
+	case "port":
+		port, _ := strconv.ParseFloat(e.attrs().Port, 64)
+		return port
+	case "sub":
+		return float64(e.node.Base.SubId)
+	case "delay":
+		return float64(e.node.Info.Delay.Average())
+	case "speed_up":
+		return float64(e.node.Info.SpeedUp.Average())
+	case "speed_down":
+		return float64(e.node.Info.SpeedDown.Average())
+	case "risk":
+		return float64(e.node.Info.Risk)
+	}
+	return 0
+}
+
+func (e *nodeEnv) boolField(field string) bool {
+	switch field {
+	case "tls":
+		a := e.attrs()
+		return a.TLS || len(a.Reality) > 0 || slices.Contains(tlsProtocol, a.Type)
+	case "alive":
+		return e.node.Info.AliveStatus&nodeModel.Alive != 0
+	}
+	return false
+}
+
+func (e *nodeEnv) listField(field string) []string {
+	if field != "tags" {
+		return nil
+	}
+	subID := e.node.Base.SubId
+	tags, ok := e.filter.tags[subID]
+	if !ok {
+		tags = op.GetSubTagsByID(context.Background(), subID)
+		e.filter.tags[subID] = tags
+	}
+	return tags
+}
@@ -5,6 +5,7 @@ import nodeModel "github.com/bestruirui/bestsub/internal/models/node"
 func RefreshInfo() {
 	refreshMutex.Lock()
 	defer refreshMutex.Unlock()
+	generation.Add(1)
 
 	for k := range subAggBuf {
 		delete(subAggBuf, k)
@@ -21,6 +22,9 @@ func RefreshInfo() {
 			subAggBuf[n.Base.SubId] = s
 		}
 		s.count++
+		if n.Info.AliveStatus&nodeModel.Alive != 0 {
+			s.alive++
+		}
 		s.sumSpeedUp += uint64(n.Info.SpeedUp.Average())
 		s.sumSpeedDown += uint64(n.Info.SpeedDown.Average())
 		s.sumDelay += uint64(n.Info.Delay.Average())
@@ -32,6 +36,9 @@ func RefreshInfo() {
 			countryAggBuf[n.Info.Country] = c
 		}
 		c.count++
+		if n.Info.AliveStatus&nodeModel.Alive != 0 {
+			c.alive++
+		}
 		c.sumSpeedUp += uint64(n.Info.SpeedUp.Average())
 		c.sumSpeedDown += uint64(n.Info.SpeedDown.Average())
 		c.sumDelay += uint64(n.Info.Delay.Average())
@@ -52,6 +59,7 @@ func RefreshInfo() {
 		}
 		subInfoMap[subID] = nodeModel.SimpleInfo{
 			Count:     s.count,
+			Alive:     s.alive,
 			SpeedUp:   uint32(s.sumSpeedUp / uint64(s.count)),
 			SpeedDown: uint32(s.sumSpeedDown / uint64(s.count)),
 			Delay:     uint16(s.sumDelay / uint64(s.count)),
@@ -64,6 +72,7 @@ func RefreshInfo() {
 		}
 		countryInfoMap[country] = nodeModel.SimpleInfo{
 			Count:     c.count,
+			Alive:     c.alive,
 			SpeedUp:   uint32(c.sumSpeedUp / uint64(c.count)),
 			SpeedDown: uint32(c.sumSpeedDown / uint64(c.count)),
 			Delay:     uint16(c.sumDelay / uint64(c.count)),
@@ -46,6 +46,7 @@ func InitNodePool(size int) {
 	for _, node := range pool {
 		nodeExist.Add(node.Base.UniqueKey)
 	}
+	generation.Add(1)
 }
 
 func CloseNodePool() error {
@@ -143,8 +144,11 @@ func Add(node *[]nodeModel.Base) int {
 
 			}
 		}()
-		if !wgStatus {
-			wgStatus = true
+		validMutex.Lock()
+		start := !wgStatus
+		wgStatus = true
+		validMutex.Unlock()
+		if start {
 			go func() {
 				time.Sleep(time.Second * 5)
 				wgSync.Wait()
@@ -154,14 +158,33 @@ func Add(node *[]nodeModel.Base) int {
 					RefreshInfo()
 				}
 				log.Infof("Receipt successful, %d new nodes added", mergedNodes)
+				validMutex.Lock()
 				validNodes = validNodes[:0]
 				wgStatus = false
+				done := mergeDone
+				mergeDone = nil
+				validMutex.Unlock()
+				for _, fn := range done {
+					fn()
+				}
 			}()
 		}
 	}
 	return len(nodesToProcess)
 }
 
+// AfterMerge 在正在测试的新节点合并到节点池后调用 fn，没有待合并的节点时立即调用
+func AfterMerge(fn func()) {
+	validMutex.Lock()
+	if wgStatus {
+		mergeDone = append(mergeDone, fn)
+		validMutex.Unlock()
+		return
+	}
+	validMutex.Unlock()
+	fn()
+}
+
 func ForEach(fn func(node []byte)) {
 	poolMutex.RLock()
 	defer poolMutex.RUnlock()
@@ -201,44 +224,71 @@ func GetBySubId(subId []uint16) *[]nodeModel.Data {
 }
 
 func GetByFilter(filter nodeModel.Filter) *[]nodeModel.Data {
+	return GetByFilters(filter)
+}
+
+// GetByFilters 获取同时满足所有过滤条件的节点
+func GetByFilters(filters ...nodeModel.Filter) *[]nodeModel.Data {
+	var result []nodeModel.Data
+	extras := make([]*compiledFilter, len(filters))
+	for i, filter := range filters {
+		extra, err := compileFilter(filter)
+		if err != nil {
+			log.Warnf("invalid node filter: %v", err)
+			return &result
+		}
+		extras[i] = extra
+	}
 	poolMutex.RLock()
 	defer poolMutex.RUnlock()
-	var result []nodeModel.Data
 	for _, node := range pool {
-		if len(filter.SubId) > 0 {
-			if filter.SubIdExclude && slices.Contains(filter.SubId, node.Base.SubId) {
-				continue
-			}
-			if !filter.SubIdExclude && !slices.Contains(filter.SubId, node.Base.SubId) {
-				continue
+		matched := true
+		for i := range filters {
+			if !matchFilter(&filters[i], extras[i], &node) {
+				matched = false
+				break
 			}
 		}
-		if filter.AliveStatus != 0 && node.Info.AliveStatus&filter.AliveStatus != filter.AliveStatus {
-			continue
-		}
-		if len(filter.Country) > 0 {
-			if filter.CountryExclude && slices.Contains(filter.Country, node.Info.Country) {
-				continue
-			}
-			if !filter.CountryExclude && !slices.Contains(filter.Country, node.Info.Country) {
-				continue
-			}
+		if matched {
+			result = append(result, node)
 		}
-		if filter.SpeedUpMore != 0 && node.Info.SpeedUp.Average() < filter.SpeedUpMore {
-			continue
+	}
+	return &result
+}
+
+func matchFilter(filter *nodeModel.Filter, extra *compiledFilter, node *nodeModel.Data) bool {
+	if len(filter.SubId) > 0 {
+		if filter.SubIdExclude && slices.Contains(filter.SubId, node.Base.SubId) {
+			return false
 		}
-		if filter.SpeedDownMore != 0 && node.Info.SpeedDown.Average() < filter.SpeedDownMore {
-			continue
+		if !filter.SubIdExclude && !slices.Contains(filter.SubId, node.Base.SubId) {
+			return false
 		}
-		if filter.DelayLessThan != 0 && node.Info.Delay.Average() > filter.DelayLessThan {
-			continue
+	}
+	if filter.AliveStatus != 0 && node.Info.AliveStatus&filter.AliveStatus != filter.AliveStatus {
+		return false
+	}
+	if len(filter.Country) > 0 {
+		if filter.CountryExclude && slices.Contains(filter.Country, node.Info.Country) {
+			return false
 		}
-		if filter.RiskLessThan != 0 && node.Info.Risk > filter.RiskLessThan {
-			continue
+		if !filter.CountryExclude && !slices.Contains(filter.Country, node.Info.Country) {
+			return false
 		}
-		result = append(result, node)
 	}
-	return &result
+	if filter.SpeedUpMore != 0 && node.Info.SpeedUp.Average() < filter.SpeedUpMore {
+		return false
+	}
+	if filter.SpeedDownMore != 0 && node.Info.SpeedDown.Average() < filter.SpeedDownMore {
+		return false
+	}
+	if filter.DelayLessThan != 0 && node.Info.Delay.Average() > filter.DelayLessThan {
+		return false
+	}
+	if filter.RiskLessThan != 0 && node.Info.Risk > filter.RiskLessThan {
+		return false
+	}
+	return extra.match(node)
 }
 
 func mergeNodesToPool(newNodes []nodeModel.Data) int {
@@ -269,6 +319,10 @@ func mergeNodesToPool(newNodes []nodeModel.Data) int {
 		}
 	}
 
+	newNodes = slices.DeleteFunc(newNodes, func(n nodeModel.Data) bool {
+		return IsLowPriority(n.Base.SubId)
+	})
+
 	sort.Slice(pool, func(i, j int) bool {
 		return pool[i].Info.Delay.Average() < pool[j].Info.Delay.Average()
 	})
@@ -289,6 +343,31 @@ func mergeNodesToPool(newNodes []nodeModel.Data) int {
 	return 0
 }
 
+// SetLowPriority 低优先级订阅的节点只在节点池有空余时加入，不替换已有节点
+func SetLowPriority(subID uint16, low bool) {
+	if low {
+		lowPriority.Store(subID, true)
+	} else {
+		lowPriority.Delete(subID)
+	}
+}
+
+func IsLowPriority(subID uint16) bool {
+	low, _ := lowPriority.Load(subID)
+	return low
+}
+
+// Generation 节点池的版本号
+func Generation() uint64 {
+	return generation.Load()
+}
+
+func Count() int {
+	poolMutex.RLock()
+	defer poolMutex.RUnlock()
+	return len(pool)
+}
+
 func GetSubInfo(subID uint16) nodeModel.SimpleInfo {
 	refreshMutex.Lock()
 	defer refreshMutex.Unlock()
@@ -301,8 +380,10 @@ func GetCountryInfo(country string) nodeModel.SimpleInfo {
 	return countryInfoMap[country]
 }
 func DeleteBySubId(subID uint16) {
+	lowPriority.Delete(subID)
 	poolMutex.Lock()
 	defer poolMutex.Unlock()
+	defer generation.Add(1)
 	
 	end := len(pool) - 1
 	for i := 0; i <= end; {
@@ -2,8 +2,10 @@ package node
 
 import (
 	"sync"
+	"sync/atomic"
 
 	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
 )
 
 var (
@@ -16,12 +18,19 @@ var (
 	wgStatus   bool
 	validNodes []nodeModel.Data
 	validMutex sync.Mutex
+	// mergeDone 等待本轮合并完成的回调
+	mergeDone []func()
 
 	refreshMutex   sync.Mutex
 	subInfoMap     = make(map[uint16]nodeModel.SimpleInfo)
 	countryInfoMap = make(map[string]nodeModel.SimpleInfo)
 	subAggBuf      = make(map[uint16]*infoSums)
 	countryAggBuf  = make(map[string]*infoSums)
+
+	lowPriority = generic.MapOf[uint16, bool]{}
+
+	// generation 节点池内容或节点信息变化时递增，用于判断缓存是否失效
+	generation atomic.Uint64
 )
 
 type infoSums struct {
@@ -30,4 +39,5 @@ type infoSums struct {
 	sumDelay     uint64
 	sumRisk      uint64
 	count        uint32
+	alive        uint32
 }
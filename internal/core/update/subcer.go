@@ -13,6 +13,10 @@ import (
 )
 
 func InitSubconverter() error {
+	if subcer.Remote() {
+		log.Infof("using remote subconverter, skip download")
+		return nil
+	}
 	filePath := config.Base().SubConverter.Path + "/subconverter"
 	if runtime.GOOS == "windows" {
 		filePath += ".exe"
@@ -26,7 +30,7 @@ func InitSubconverter() error {
 			return err
 		}
 		if _, err := os.Stat(filePath); err != nil {
-			log.Warnf("subconverter not found, please download subconverter manually from %s and move to %s: %v", op.GetSettingStr(setting.SUBCONVERTER_URL), config.Base().SubConverter.Path, err)
+			log.Warnf("subconverter not found, please download subconverter manually from %s and move to %s, or set subconverter.url to use a remote subconverter: %v", op.GetSettingStr(setting.SUBCONVERTER_URL), config.Base().SubConverter.Path, err)
 			os.Exit(1)
 			return err
 		}
@@ -36,6 +40,9 @@ func InitSubconverter() error {
 }
 
 func UpdateSubconverter() error {
+	if subcer.Remote() {
+		return fmt.Errorf("remote subconverter is managed externally")
+	}
 	log.Infof("start update subconverter")
 	err := updateSubconverter()
 	if err != nil {
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type ShareAccessRepository struct {
+	db *DB
+}
+
+func (db *DB) ShareAccess() interfaces.ShareAccessRepository {
+	return &ShareAccessRepository{db: db}
+}
+
+func (r *ShareAccessRepository) BatchCreate(ctx context.Context, logs *[]share.AccessLog) error {
+	if logs == nil || len(*logs) == 0 {
+		return nil
+	}
+	log.Debugf("Batch create share access log for %d items", len(*logs))
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO share_access_log (share_id, accessed_at, ip, user_agent, format, node_count, bytes)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, l := range *logs {
+		_, err := stmt.ExecContext(ctx, l.ShareID, l.AccessedAt, l.IP, l.UserAgent, l.Format, l.NodeCount, l.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to create share access log for share %d: %w", l.ShareID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ShareAccessRepository) List(ctx context.Context, shareID uint16, limit int) (*[]share.AccessLog, error) {
+	log.Debugf("List share access log")
+	query := `SELECT id, share_id, accessed_at, ip, user_agent, format, node_count, bytes
+	          FROM share_access_log WHERE share_id = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.db.db.QueryContext(ctx, query, shareID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share access log: %w", err)
+	}
+	defer rows.Close()
+
+	logs := make([]share.AccessLog, 0)
+	for rows.Next() {
+		var l share.AccessLog
+		err := rows.Scan(&l.ID, &l.ShareID, &l.AccessedAt, &l.IP, &l.UserAgent, &l.Format, &l.NodeCount, &l.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share access log: %w", err)
+		}
+		logs = append(logs, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate share access log: %w", err)
+	}
+
+	return &logs, nil
+}
+
+func (r *ShareAccessRepository) Stats(ctx context.Context, shareID uint16) (*share.AccessStats, error) {
+	log.Debugf("Stats share access log")
+	var stats share.AccessStats
+	err := r.db.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COUNT(DISTINCT ip), COUNT(DISTINCT user_agent) FROM share_access_log WHERE share_id = ?`,
+		shareID,
+	).Scan(&stats.Total, &stats.DistinctIP, &stats.DistinctUA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stats share access log: %w", err)
+	}
+
+	query := `SELECT ip, COUNT(*), COUNT(DISTINCT user_agent), MIN(accessed_at), MAX(accessed_at),
+	                 (SELECT user_agent FROM share_access_log l WHERE l.share_id = a.share_id AND l.ip = a.ip ORDER BY id DESC LIMIT 1)
+	          FROM share_access_log a WHERE share_id = ? GROUP BY ip ORDER BY MAX(accessed_at) DESC`
+	rows, err := r.db.db.QueryContext(ctx, query, shareID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stats share access clients: %w", err)
+	}
+	defer rows.Close()
+
+	stats.Clients = make([]share.AccessClient, 0)
+	for rows.Next() {
+		var c share.AccessClient
+		if err := rows.Scan(&c.IP, &c.Count, &c.UserAgents, &c.FirstSeen, &c.LastSeen, &c.LastUserAgent); err != nil {
+			return nil, fmt.Errorf("failed to scan share access client: %w", err)
+		}
+		stats.Clients = append(stats.Clients, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate share access clients: %w", err)
+	}
+
+	return &stats, nil
+}
+
+func (r *ShareAccessRepository) Prune(ctx context.Context, shareID uint16, keep int) error {
+	log.Debugf("Prune share access log")
+	query := `DELETE FROM share_access_log WHERE share_id = ? AND id NOT IN (
+	          SELECT id FROM share_access_log WHERE share_id = ? ORDER BY id DESC LIMIT ?)`
+
+	_, err := r.db.db.ExecContext(ctx, query, shareID, shareID, keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune share access log: %w", err)
+	}
+
+	return nil
+}
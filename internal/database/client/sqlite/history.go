@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type SubHistoryRepository struct {
+	db *DB
+}
+
+func (db *DB) SubHistory() interfaces.SubHistoryRepository {
+	return &SubHistoryRepository{db: db}
+}
+
+func (r *SubHistoryRepository) Create(ctx context.Context, h *sub.History) error {
+	log.Debugf("Create sub fetch history")
+	query := `INSERT INTO sub_fetch_history (sub_id, fetched_at, duration, status_code, raw_count, filtered_count, admitted_count, error, added, removed, nodes)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.db.ExecContext(ctx, query,
+		h.SubID,
+		h.FetchedAt,
+		h.Duration,
+		h.StatusCode,
+		h.RawCount,
+		h.FilteredCount,
+		h.AdmittedCount,
+		h.Error,
+		h.Added,
+		h.Removed,
+		h.Nodes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sub fetch history: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get sub fetch history id: %w", err)
+	}
+	h.ID = uint32(id)
+
+	return nil
+}
+
+func (r *SubHistoryRepository) GetLatest(ctx context.Context, subID uint16) (*sub.History, error) {
+	log.Debugf("Get latest sub fetch history")
+	query := `SELECT id, sub_id, fetched_at, duration, status_code, raw_count, filtered_count, admitted_count, error, added, removed, nodes
+	          FROM sub_fetch_history WHERE sub_id = ? ORDER BY id DESC LIMIT 1`
+
+	var h sub.History
+	err := r.db.db.QueryRowContext(ctx, query, subID).Scan(
+		&h.ID,
+		&h.SubID,
+		&h.FetchedAt,
+		&h.Duration,
+		&h.StatusCode,
+		&h.RawCount,
+		&h.FilteredCount,
+		&h.AdmittedCount,
+		&h.Error,
+		&h.Added,
+		&h.Removed,
+		&h.Nodes,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest sub fetch history: %w", err)
+	}
+
+	return &h, nil
+}
+
+func (r *SubHistoryRepository) ListBySubID(ctx context.Context, subID uint16, limit int) (*[]sub.History, error) {
+	log.Debugf("List sub fetch history")
+	query := `SELECT id, sub_id, fetched_at, duration, status_code, raw_count, filtered_count, admitted_count, error, added, removed
+	          FROM sub_fetch_history WHERE sub_id = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.db.db.QueryContext(ctx, query, subID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sub fetch history: %w", err)
+	}
+	defer rows.Close()
+
+	var histories []sub.History
+	for rows.Next() {
+		var h sub.History
+		err := rows.Scan(
+			&h.ID,
+			&h.SubID,
+			&h.FetchedAt,
+			&h.Duration,
+			&h.StatusCode,
+			&h.RawCount,
+			&h.FilteredCount,
+			&h.AdmittedCount,
+			&h.Error,
+			&h.Added,
+			&h.Removed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sub fetch history: %w", err)
+		}
+		histories = append(histories, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sub fetch history: %w", err)
+	}
+
+	return &histories, nil
+}
+
+func (r *SubHistoryRepository) Prune(ctx context.Context, subID uint16, keep int) error {
+	log.Debugf("Prune sub fetch history")
+	query := `DELETE FROM sub_fetch_history WHERE sub_id = ? AND id NOT IN (
+	          SELECT id FROM sub_fetch_history WHERE sub_id = ? ORDER BY id DESC LIMIT ?)`
+
+	_, err := r.db.db.ExecContext(ctx, query, subID, subID, keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune sub fetch history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration003AddSubFetchHistory 添加订阅获取历史表
+func Migration003AddSubFetchHistory() string {
+	return `
+CREATE TABLE IF NOT EXISTS "sub_fetch_history" (
+	"id" INTEGER NOT NULL,
+	"sub_id" INTEGER NOT NULL,
+	"fetched_at" DATETIME NOT NULL,
+	"duration" INTEGER NOT NULL DEFAULT 0,
+	"status_code" INTEGER NOT NULL DEFAULT 0,
+	"raw_count" INTEGER NOT NULL DEFAULT 0,
+	"filtered_count" INTEGER NOT NULL DEFAULT 0,
+	"admitted_count" INTEGER NOT NULL DEFAULT 0,
+	"error" TEXT NOT NULL DEFAULT '',
+	"added" TEXT NOT NULL DEFAULT '[]',
+	"removed" TEXT NOT NULL DEFAULT '[]',
+	"nodes" TEXT NOT NULL DEFAULT '[]',
+	PRIMARY KEY("id"),
+	FOREIGN KEY("sub_id") REFERENCES "sub"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_sub_fetch_history_sub_id" ON "sub_fetch_history" ("sub_id", "id");
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202511201000, "dev", "Add Sub Fetch History", Migration003AddSubFetchHistory)
+}
@@ -0,0 +1,26 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration004AddSubTemplateVersion 为规则模板添加版本记录
+func Migration004AddSubTemplateVersion() string {
+	return `
+ALTER TABLE "sub_template" ADD COLUMN "description" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "sub_template" ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE "sub_template" ADD COLUMN "updated_at" DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+CREATE TABLE IF NOT EXISTS "sub_template_version" (
+	"template_id" INTEGER NOT NULL,
+	"version" INTEGER NOT NULL,
+	"template" TEXT NOT NULL,
+	"created_at" DATETIME NOT NULL,
+	PRIMARY KEY("template_id", "version"),
+	FOREIGN KEY("template_id") REFERENCES "sub_template"("id") ON DELETE CASCADE
+);
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202511211000, "dev", "Add Sub Template Version", Migration004AddSubTemplateVersion)
+}
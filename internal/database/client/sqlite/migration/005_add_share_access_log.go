@@ -0,0 +1,28 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration005AddShareAccessLog 添加分享访问记录表
+func Migration005AddShareAccessLog() string {
+	return `
+CREATE TABLE IF NOT EXISTS "share_access_log" (
+	"id" INTEGER NOT NULL,
+	"share_id" INTEGER NOT NULL,
+	"accessed_at" INTEGER NOT NULL,
+	"ip" TEXT NOT NULL DEFAULT '',
+	"user_agent" TEXT NOT NULL DEFAULT '',
+	"format" TEXT NOT NULL DEFAULT '',
+	"node_count" INTEGER NOT NULL DEFAULT 0,
+	"bytes" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("share_id") REFERENCES "share"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_share_access_log_share_id" ON "share_access_log" ("share_id", "id");
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202511221000, "dev", "Add Share Access Log", Migration005AddShareAccessLog)
+}
@@ -0,0 +1,18 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration006AddShareAccessControl 添加分享访问控制与token更换字段
+func Migration006AddShareAccessControl() string {
+	return `
+ALTER TABLE "share" ADD COLUMN "access" TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE "share" ADD COLUMN "prev_token" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "share" ADD COLUMN "prev_token_expires" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "share" ADD COLUMN "token_rotated_at" INTEGER NOT NULL DEFAULT 0;
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202511231000, "dev", "Add Share Access Control", Migration006AddShareAccessControl)
+}
@@ -20,8 +20,8 @@ func (db *DB) Share() interfaces.ShareRepository {
 
 func (r *ShareRepository) Create(ctx context.Context, shareData *share.Data) error {
 	log.Debugf("Create share")
-	query := `INSERT INTO share (enable, name, token, gen, max_access_count, expires)
-	          VALUES (?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO share (enable, name, token, gen, max_access_count, expires, access, prev_token, prev_token_expires, token_rotated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.db.db.ExecContext(ctx, query,
 		shareData.Enable,
@@ -30,6 +30,10 @@ func (r *ShareRepository) Create(ctx context.Context, shareData *share.Data) err
 		shareData.Gen,
 		shareData.MaxAccessCount,
 		shareData.Expires,
+		shareData.Access,
+		shareData.PrevToken,
+		shareData.PrevTokenExpires,
+		shareData.TokenRotatedAt,
 	)
 
 	if err != nil {
@@ -48,7 +52,7 @@ func (r *ShareRepository) Create(ctx context.Context, shareData *share.Data) err
 
 func (r *ShareRepository) GetByID(ctx context.Context, id uint16) (*share.Data, error) {
 	log.Debugf("Get share by id")
-	query := `SELECT id, enable, name, token, gen, access_count, expires, max_access_count
+	query := `SELECT id, enable, name, token, gen, access_count, expires, max_access_count, access, prev_token, prev_token_expires, token_rotated_at
 	          FROM share WHERE id = ?`
 
 	var shareData share.Data
@@ -61,6 +65,10 @@ func (r *ShareRepository) GetByID(ctx context.Context, id uint16) (*share.Data,
 		&shareData.AccessCount,
 		&shareData.Expires,
 		&shareData.MaxAccessCount,
+		&shareData.Access,
+		&shareData.PrevToken,
+		&shareData.PrevTokenExpires,
+		&shareData.TokenRotatedAt,
 	)
 
 	if err != nil {
@@ -75,7 +83,8 @@ func (r *ShareRepository) GetByID(ctx context.Context, id uint16) (*share.Data,
 
 func (r *ShareRepository) Update(ctx context.Context, shareData *share.Data) error {
 	log.Debugf("Update share")
-	query := `UPDATE share SET enable = ?, name = ?, token = ?, gen = ?, access_count = ?, max_access_count = ?, expires = ? WHERE id = ?`
+	query := `UPDATE share SET enable = ?, name = ?, token = ?, gen = ?, access_count = ?, max_access_count = ?, expires = ?,
+	          access = ?, prev_token = ?, prev_token_expires = ?, token_rotated_at = ? WHERE id = ?`
 
 	_, err := r.db.db.ExecContext(ctx, query,
 		shareData.Enable,
@@ -85,6 +94,10 @@ func (r *ShareRepository) Update(ctx context.Context, shareData *share.Data) err
 		shareData.AccessCount,
 		shareData.MaxAccessCount,
 		shareData.Expires,
+		shareData.Access,
+		shareData.PrevToken,
+		shareData.PrevTokenExpires,
+		shareData.TokenRotatedAt,
 		shareData.ID,
 	)
 
@@ -142,7 +155,7 @@ func (r *ShareRepository) Delete(ctx context.Context, id uint16) error {
 
 func (r *ShareRepository) List(ctx context.Context) (*[]share.Data, error) {
 	log.Debugf("List share")
-	query := `SELECT id, enable, name, token, gen, access_count, expires, max_access_count
+	query := `SELECT id, enable, name, token, gen, access_count, expires, max_access_count, access, prev_token, prev_token_expires, token_rotated_at
 	          FROM share`
 
 	rows, err := r.db.db.QueryContext(ctx, query)
@@ -163,6 +176,10 @@ func (r *ShareRepository) List(ctx context.Context) (*[]share.Data, error) {
 			&shareData.AccessCount,
 			&shareData.Expires,
 			&shareData.MaxAccessCount,
+			&shareData.Access,
+			&shareData.PrevToken,
+			&shareData.PrevTokenExpires,
+			&shareData.TokenRotatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan share link: %w", err)
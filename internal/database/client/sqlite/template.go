@@ -0,0 +1,206 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type TemplateRepository struct {
+	db *DB
+}
+
+func (db *DB) Template() interfaces.TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+func (r *TemplateRepository) Create(ctx context.Context, t *share.Template) error {
+	log.Debugf("Create sub template")
+	query := `INSERT INTO sub_template (name, type, description, template, version, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+
+	t.Version = 1
+	t.UpdatedAt = time.Now()
+	result, err := r.db.db.ExecContext(ctx, query,
+		t.Name,
+		t.Type,
+		t.Description,
+		t.Template,
+		t.Version,
+		t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sub template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get sub template id: %w", err)
+	}
+	t.ID = uint16(id)
+
+	return nil
+}
+
+func (r *TemplateRepository) GetByID(ctx context.Context, id uint16) (*share.Template, error) {
+	log.Debugf("Get sub template by id")
+	query := `SELECT id, name, type, description, template, version, updated_at
+	          FROM sub_template WHERE id = ?`
+
+	var t share.Template
+	err := r.db.db.QueryRowContext(ctx, query, id).Scan(
+		&t.ID,
+		&t.Name,
+		&t.Type,
+		&t.Description,
+		&t.Template,
+		&t.Version,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sub template by id: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *TemplateRepository) Update(ctx context.Context, t *share.Template) error {
+	log.Debugf("Update sub template")
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version uint32
+	if err := tx.QueryRowContext(ctx, `SELECT version FROM sub_template WHERE id = ?`, t.ID).Scan(&version); err != nil {
+		return fmt.Errorf("failed to get sub template version: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO sub_template_version (template_id, version, template, created_at)
+	          SELECT id, version, template, updated_at FROM sub_template WHERE id = ?`, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save sub template version: %w", err)
+	}
+
+	t.Version = version + 1
+	t.UpdatedAt = time.Now()
+	_, err = tx.ExecContext(ctx, `UPDATE sub_template SET name = ?, type = ?, description = ?, template = ?, version = ?, updated_at = ? WHERE id = ?`,
+		t.Name,
+		t.Type,
+		t.Description,
+		t.Template,
+		t.Version,
+		t.UpdatedAt,
+		t.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sub template: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, id uint16) error {
+	log.Debugf("Delete sub template")
+	query := `DELETE FROM sub_template WHERE id = ?`
+
+	_, err := r.db.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sub template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) List(ctx context.Context) (*[]share.Template, error) {
+	log.Debugf("List sub template")
+	query := `SELECT id, name, type, description, template, version, updated_at
+	          FROM sub_template ORDER BY id`
+
+	rows, err := r.db.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sub templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []share.Template
+	for rows.Next() {
+		var t share.Template
+		err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Type,
+			&t.Description,
+			&t.Template,
+			&t.Version,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sub template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sub templates: %w", err)
+	}
+
+	return &templates, nil
+}
+
+func (r *TemplateRepository) ListVersions(ctx context.Context, id uint16) (*[]share.TemplateVersion, error) {
+	log.Debugf("List sub template versions")
+	query := `SELECT template_id, version, template, created_at
+	          FROM sub_template_version WHERE template_id = ? ORDER BY version DESC`
+
+	rows, err := r.db.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sub template versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []share.TemplateVersion
+	for rows.Next() {
+		var v share.TemplateVersion
+		if err := rows.Scan(&v.TemplateID, &v.Version, &v.Template, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sub template version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sub template versions: %w", err)
+	}
+
+	return &versions, nil
+}
+
+func (r *TemplateRepository) GetVersion(ctx context.Context, id uint16, version uint32) (*share.TemplateVersion, error) {
+	log.Debugf("Get sub template version")
+	query := `SELECT template_id, version, template, created_at
+	          FROM sub_template_version WHERE template_id = ? AND version = ?`
+
+	var v share.TemplateVersion
+	err := r.db.db.QueryRowContext(ctx, query, id, version).Scan(&v.TemplateID, &v.Version, &v.Template, &v.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sub template version: %w", err)
+	}
+
+	return &v, nil
+}
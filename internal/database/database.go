@@ -34,6 +34,9 @@ func Initialize(sqltype, path string) error {
 	if err := initNotifyTemplate(context.Background(), op.NotifyTemplateRepo()); err != nil {
 		log.Fatalf("failed to initialize notify templates: %v", err)
 	}
+	if err := initTemplate(context.Background(), op.TemplateRepo()); err != nil {
+		log.Fatalf("failed to initialize sub templates: %v", err)
+	}
 	return nil
 }
 func Close() error {
@@ -8,6 +8,7 @@ import (
 	authModel "github.com/bestruirui/bestsub/internal/models/auth"
 	"github.com/bestruirui/bestsub/internal/models/notify"
 	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/models/share"
 	"github.com/bestruirui/bestsub/internal/utils/log"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -82,3 +83,20 @@ func initNotifyTemplate(ctx context.Context, notifyTemplateRepo interfaces.Notif
 	}
 	return nil
 }
+
+// initTemplate 模板表为空时写入示例模板
+func initTemplate(ctx context.Context, templateRepo interfaces.TemplateRepository) error {
+	existingTemplates, err := templateRepo.List(ctx)
+	if err != nil {
+		log.Fatalf("failed to get existing sub templates: %v", err)
+	}
+	if len(*existingTemplates) > 0 {
+		return nil
+	}
+	for _, template := range share.DefaultTemplates() {
+		if err := templateRepo.Create(ctx, &template); err != nil {
+			log.Fatalf("failed to create default sub template %s: %v", template.Name, err)
+		}
+	}
+	return nil
+}
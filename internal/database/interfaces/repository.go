@@ -12,7 +12,10 @@ type Repository interface {
 	Check() CheckRepository
 
 	Sub() SubRepository
+	SubHistory() SubHistoryRepository
 	Share() ShareRepository
+	ShareAccess() ShareAccessRepository
+	Template() TemplateRepository
 
 	Storage() StorageRepository
 
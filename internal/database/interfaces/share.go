@@ -29,3 +29,18 @@ type ShareRepository interface {
 	// List 获取分享链接列表
 	List(ctx context.Context) (*[]share.Data, error)
 }
+
+// ShareAccessRepository 分享访问记录数据访问接口
+type ShareAccessRepository interface {
+	// BatchCreate 批量写入访问记录
+	BatchCreate(ctx context.Context, logs *[]share.AccessLog) error
+
+	// List 获取分享链接最近的访问记录
+	List(ctx context.Context, shareID uint16, limit int) (*[]share.AccessLog, error)
+
+	// Stats 按客户端IP统计访问记录
+	Stats(ctx context.Context, shareID uint16) (*share.AccessStats, error)
+
+	// Prune 仅保留分享链接最近的 keep 条记录
+	Prune(ctx context.Context, shareID uint16, keep int) error
+}
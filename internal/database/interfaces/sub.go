@@ -26,3 +26,18 @@ type SubRepository interface {
 	// BatchCreate 批量创建订阅链接
 	BatchCreate(ctx context.Context, links []*sub.Data) error
 }
+
+// SubHistoryRepository 订阅获取历史数据访问接口
+type SubHistoryRepository interface {
+	// Create 创建获取记录
+	Create(ctx context.Context, history *sub.History) error
+
+	// GetLatest 获取订阅最近一次的获取记录
+	GetLatest(ctx context.Context, subID uint16) (*sub.History, error)
+
+	// ListBySubID 获取订阅的获取记录，按时间倒序
+	ListBySubID(ctx context.Context, subID uint16, limit int) (*[]sub.History, error)
+
+	// Prune 仅保留订阅最近的 keep 条记录
+	Prune(ctx context.Context, subID uint16, keep int) error
+}
@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bestruirui/bestsub/internal/models/share"
+)
+
+// TemplateRepository 规则模板数据访问接口
+type TemplateRepository interface {
+	// Create 创建模板
+	Create(ctx context.Context, template *share.Template) error
+
+	// GetByID 根据ID获取模板
+	GetByID(ctx context.Context, id uint16) (*share.Template, error)
+
+	// Update 更新模板，同时保存旧版本
+	Update(ctx context.Context, template *share.Template) error
+
+	// Delete 删除模板
+	Delete(ctx context.Context, id uint16) error
+
+	// List 获取模板列表
+	List(ctx context.Context) (*[]share.Template, error)
+
+	// ListVersions 获取模板的历史版本
+	ListVersions(ctx context.Context, id uint16) (*[]share.TemplateVersion, error)
+
+	// GetVersion 获取模板的指定历史版本
+	GetVersion(ctx context.Context, id uint16, version uint32) (*share.TemplateVersion, error)
+}
@@ -11,5 +11,6 @@ func SetRepo(repository interfaces.Repository) {
 }
 func Close() error {
 	updateAccessCount()
+	flushShareAccessLog()
 	return repo.Close()
 }
@@ -3,14 +3,18 @@ package op
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/setting"
 	"github.com/bestruirui/bestsub/internal/models/share"
 	"github.com/bestruirui/bestsub/internal/utils/cache"
 	"github.com/bestruirui/bestsub/internal/utils/generic"
 	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/google/uuid"
 )
 
 var shareRepo interfaces.ShareRepository
@@ -19,6 +23,10 @@ var shareCache = cache.New[uint16, share.Data](16)
 var pendingUpdates = &generic.MapOf[uint16, bool]{}
 var startOnce sync.Once
 
+var shareAccessRepo interfaces.ShareAccessRepository
+var accessLogMutex sync.Mutex
+var accessLogBuffer []share.AccessLog
+
 func ShareRepo() interfaces.ShareRepository {
 	if shareRepo == nil {
 		shareRepo = repo.Share()
@@ -58,8 +66,9 @@ func GetShareByToken(ctx context.Context, token string) (*share.Data, error) {
 			return nil, err
 		}
 	}
+	now := uint64(time.Now().Unix())
 	for _, s := range shareCache.GetAll() {
-		if s.Token == token {
+		if s.MatchToken(token, now) {
 			return &s, nil
 		}
 	}
@@ -71,6 +80,7 @@ func CreateShare(ctx context.Context, share *share.Data) error {
 			return err
 		}
 	}
+	share.TokenRotatedAt = uint64(time.Now().Unix())
 	if err := ShareRepo().Create(ctx, share); err != nil {
 		return err
 	}
@@ -88,6 +98,16 @@ func UpdateShare(ctx context.Context, share *share.Data) error {
 		return fmt.Errorf("share not found")
 	}
 	share.AccessCount = oldShare.AccessCount
+	if share.Token == oldShare.Token {
+		share.PrevToken = oldShare.PrevToken
+		share.PrevTokenExpires = oldShare.PrevTokenExpires
+		share.TokenRotatedAt = oldShare.TokenRotatedAt
+		if share.TokenRotatedAt == 0 {
+			share.TokenRotatedAt = uint64(time.Now().Unix())
+		}
+	} else {
+		share.TokenRotatedAt = uint64(time.Now().Unix())
+	}
 	if err := ShareRepo().Update(ctx, share); err != nil {
 		return err
 	}
@@ -95,6 +115,29 @@ func UpdateShare(ctx context.Context, share *share.Data) error {
 	return nil
 }
 
+// RotateShareToken 更换分享token，旧token在 grace 时间内仍然有效
+func RotateShareToken(ctx context.Context, id uint16, grace time.Duration) (*share.Data, error) {
+	if shareCache.Len() == 0 {
+		if err := refreshShareCache(ctx); err != nil {
+			return nil, err
+		}
+	}
+	shareData, ok := shareCache.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("share not found")
+	}
+	now := time.Now()
+	shareData.PrevToken = shareData.Token
+	shareData.PrevTokenExpires = uint64(now.Add(grace).Unix())
+	shareData.Token = strings.ReplaceAll(uuid.NewString(), "-", "")
+	shareData.TokenRotatedAt = uint64(now.Unix())
+	if err := ShareRepo().Update(ctx, &shareData); err != nil {
+		return nil, err
+	}
+	shareCache.Set(id, shareData)
+	return &shareData, nil
+}
+
 func UpdateShareAccessCount(ctx context.Context, id uint16) error {
 	if shareCache.Len() == 0 {
 		if err := refreshShareCache(ctx); err != nil {
@@ -146,6 +189,7 @@ func startScheduleUpdateAccessCount() {
 			defer ticker.Stop()
 			for range ticker.C {
 				updateAccessCount()
+				flushShareAccessLog()
 			}
 		}()
 	})
@@ -176,3 +220,68 @@ func updateAccessCount() {
 		pendingUpdates.Delete(data.ID)
 	}
 }
+
+func ShareAccessRepo() interfaces.ShareAccessRepository {
+	if shareAccessRepo == nil {
+		shareAccessRepo = repo.ShareAccess()
+	}
+	return shareAccessRepo
+}
+
+// RecordShareAccess 记录一次分享访问，与访问次数一起定时批量写入
+func RecordShareAccess(accessLog share.AccessLog) {
+	accessLogMutex.Lock()
+	accessLogBuffer = append(accessLogBuffer, accessLog)
+	accessLogMutex.Unlock()
+	startScheduleUpdateAccessCount()
+}
+
+// GetShareAccessLog 获取分享链接最近的访问记录
+func GetShareAccessLog(ctx context.Context, id uint16, limit int) ([]share.AccessLog, error) {
+	flushShareAccessLog()
+	logs, err := ShareAccessRepo().List(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return *logs, nil
+}
+
+// GetShareAccessStats 按客户端统计分享链接的访问记录
+func GetShareAccessStats(ctx context.Context, id uint16) (*share.AccessStats, error) {
+	flushShareAccessLog()
+	return ShareAccessRepo().Stats(ctx, id)
+}
+
+func flushShareAccessLog() {
+	accessLogMutex.Lock()
+	logs := accessLogBuffer
+	accessLogBuffer = nil
+	accessLogMutex.Unlock()
+	// 跳过已删除分享的记录，避免整批写入失败
+	logs = slices.DeleteFunc(logs, func(l share.AccessLog) bool {
+		_, ok := shareCache.Get(l.ShareID)
+		return !ok
+	})
+	if len(logs) == 0 {
+		return
+	}
+	ctx := context.Background()
+	if err := ShareAccessRepo().BatchCreate(ctx, &logs); err != nil {
+		log.Errorf("failed to save share access log: %v", err)
+		return
+	}
+	keep := GetSettingInt(setting.SHARE_ACCESS_LOG_LIMIT)
+	if keep <= 0 {
+		return
+	}
+	pruned := make(map[uint16]bool)
+	for _, l := range logs {
+		if pruned[l.ShareID] {
+			continue
+		}
+		pruned[l.ShareID] = true
+		if err := ShareAccessRepo().Prune(ctx, l.ShareID, keep); err != nil {
+			log.Warnf("failed to prune share access log: %v", err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 )
 
 var subRepo interfaces.SubRepository
+var subHistoryRepo interfaces.SubHistoryRepository
 var subCache = cache.New[uint16, subModel.Data](16)
 
 func SubRepo() interfaces.SubRepository {
@@ -155,6 +156,69 @@ func DeleteSub(ctx context.Context, id uint16) error {
 	subCache.Del(id)
 	return nil
 }
+func SubHistoryRepo() interfaces.SubHistoryRepository {
+	if subHistoryRepo == nil {
+		subHistoryRepo = repo.SubHistory()
+	}
+	return subHistoryRepo
+}
+
+// CreateSubHistory 记录一次获取结果，并与上一次成功的记录对比得出新增和消失的节点
+func CreateSubHistory(ctx context.Context, h *subModel.History) error {
+	prev, err := SubHistoryRepo().GetLatest(ctx, h.SubID)
+	if err != nil {
+		return err
+	}
+	prevNodes := make([]subModel.HistoryNode, 0)
+	if prev != nil {
+		json.Unmarshal([]byte(prev.Nodes), &prevNodes)
+	}
+
+	added := make([]subModel.HistoryNode, 0)
+	removed := make([]subModel.HistoryNode, 0)
+	if h.Error != "" {
+		h.Snapshot = prevNodes
+	} else {
+		prevKeys := make(map[string]struct{}, len(prevNodes))
+		for _, n := range prevNodes {
+			prevKeys[n.Key] = struct{}{}
+		}
+		curKeys := make(map[string]struct{}, len(h.Snapshot))
+		for _, n := range h.Snapshot {
+			curKeys[n.Key] = struct{}{}
+			if _, ok := prevKeys[n.Key]; !ok {
+				added = append(added, n)
+			}
+		}
+		for _, n := range prevNodes {
+			if _, ok := curKeys[n.Key]; !ok {
+				removed = append(removed, n)
+			}
+		}
+	}
+
+	addedBytes, _ := json.Marshal(added)
+	removedBytes, _ := json.Marshal(removed)
+	nodesBytes, _ := json.Marshal(h.Snapshot)
+	h.Added = string(addedBytes)
+	h.Removed = string(removedBytes)
+	h.Nodes = string(nodesBytes)
+
+	if err := SubHistoryRepo().Create(ctx, h); err != nil {
+		return err
+	}
+	if limit := GetSettingInt(setting.SUB_HISTORY_LIMIT); limit > 0 {
+		return SubHistoryRepo().Prune(ctx, h.SubID, limit)
+	}
+	return nil
+}
+func GetSubHistory(ctx context.Context, subID uint16, limit int) ([]subModel.History, error) {
+	histories, err := SubHistoryRepo().ListBySubID(ctx, subID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return *histories, nil
+}
 func refreshSubCache(ctx context.Context) error {
 	subList, err := SubRepo().List(ctx)
 	if err != nil {
@@ -0,0 +1,122 @@
+package op
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/utils/cache"
+)
+
+var templateRepo interfaces.TemplateRepository
+var templateCache = cache.New[uint16, share.Template](4)
+
+func TemplateRepo() interfaces.TemplateRepository {
+	if templateRepo == nil {
+		templateRepo = repo.Template()
+	}
+	return templateRepo
+}
+
+func GetTemplateList(ctx context.Context) ([]share.Template, error) {
+	if templateCache.Len() == 0 {
+		if err := refreshTemplateCache(ctx); err != nil {
+			return nil, err
+		}
+	}
+	templates := templateCache.GetAll()
+	result := make([]share.Template, 0, len(templates))
+	for _, v := range templates {
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func GetTemplateByID(ctx context.Context, id uint16) (*share.Template, error) {
+	if templateCache.Len() == 0 {
+		if err := refreshTemplateCache(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if t, ok := templateCache.Get(id); ok {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("template not found")
+}
+
+func CreateTemplate(ctx context.Context, t *share.Template) error {
+	if templateCache.Len() == 0 {
+		if err := refreshTemplateCache(ctx); err != nil {
+			return err
+		}
+	}
+	if err := TemplateRepo().Create(ctx, t); err != nil {
+		return err
+	}
+	templateCache.Set(t.ID, *t)
+	return nil
+}
+
+func UpdateTemplate(ctx context.Context, t *share.Template) error {
+	if templateCache.Len() == 0 {
+		if err := refreshTemplateCache(ctx); err != nil {
+			return err
+		}
+	}
+	if _, ok := templateCache.Get(t.ID); !ok {
+		return fmt.Errorf("template not found")
+	}
+	if err := TemplateRepo().Update(ctx, t); err != nil {
+		return err
+	}
+	templateCache.Set(t.ID, *t)
+	return nil
+}
+
+func DeleteTemplate(ctx context.Context, id uint16) error {
+	if err := TemplateRepo().Delete(ctx, id); err != nil {
+		return err
+	}
+	templateCache.Del(id)
+	return nil
+}
+
+func GetTemplateVersions(ctx context.Context, id uint16) ([]share.TemplateVersion, error) {
+	versions, err := TemplateRepo().ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return *versions, nil
+}
+
+// RollbackTemplate 将模板内容回滚到指定历史版本，回滚本身会产生一个新版本
+func RollbackTemplate(ctx context.Context, id uint16, version uint32) (*share.Template, error) {
+	t, err := GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	v, err := TemplateRepo().GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("template version not found")
+	}
+	t.Template = v.Template
+	if err := UpdateTemplate(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func refreshTemplateCache(ctx context.Context) error {
+	templates, err := TemplateRepo().List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range *templates {
+		templateCache.Set(t.ID, t)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/bestruirui/bestsub/internal/models/notify"
 	"github.com/bestruirui/bestsub/internal/utils/log"
 )
 
@@ -28,6 +29,8 @@ type Task struct {
 	CronExpr      string   `json:"cron_expr" example:"0 0 * * *" description:"cron表达式"`
 	Notify        bool     `json:"notify" example:"true" description:"是否通知"`
 	NotifyChannel int      `json:"notify_channel" example:"1" description:"通知渠道"`
+	NotifyMode    string   `json:"notify_mode" example:"always" description:"通知条件 always:每次 failure:仅失败 change:失败或结果变化"`
+	NotifyChange  int      `json:"notify_change" example:"20" description:"结果变化通知阈值 百分比"`
 	LogWriteFile  bool     `json:"log_write_file" example:"true" description:"是否写入日志文件"`
 	LogLevel      string   `json:"log_level" example:"info" description:"日志级别"`
 	Timeout       int      `json:"timeout" example:"60" description:"超时时间 分钟"`
@@ -41,6 +44,11 @@ type Result struct {
 	Duration int64     `json:"duration" description:"运行时长(单位:毫秒)"`
 }
 
+// Validate 检查任务配置
+func (t *Task) Validate() error {
+	return notify.ValidateTaskNotify(t.NotifyMode, t.NotifyChange)
+}
+
 type Request struct {
 	Name   string `db:"name" json:"name" example:"测试检测任务" description:"检测任务名称"`
 	Enable bool   `db:"enable" json:"enable" description:"是否启用"`
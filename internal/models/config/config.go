@@ -10,9 +10,10 @@ type Base struct {
 }
 
 type ServerConfig struct {
-	Port   int    `json:"port"`
-	Host   string `json:"host"`
-	UIPath string `json:"-"`
+	Port           int      `json:"port"`
+	Host           string   `json:"host"`
+	TrustedProxies []string `json:"trusted_proxies,omitempty"` // 信任的反向代理地址，仅这些地址传入的 X-Forwarded-For 会被用作客户端IP
+	UIPath         string   `json:"-"`
 }
 
 type DatabaseConfig struct {
@@ -36,7 +37,9 @@ type SessionConfig struct {
 }
 
 type SubConverterConfig struct {
-	Path string `json:"-"`
-	Port int    `json:"port"`
-	Host string `json:"host"`
+	Path     string `json:"-"`
+	Port     int    `json:"port"`
+	Host     string `json:"host"`
+	Url      string `json:"url,omitempty"`      // 外部 subconverter 地址，设置后不再下载和启动本地程序
+	Callback string `json:"callback,omitempty"` // 外部 subconverter 访问 BestSub 的地址，默认 http://127.0.0.1:端口
 }
@@ -23,6 +23,8 @@ type Base struct {
 	Raw       []byte
 	SubId     uint16
 	UniqueKey uint64
+	Name      string // 订阅提供的原始名称
+	Region    string // 从原始名称推断的地区代码
 }
 
 type UniqueKey struct {
@@ -51,18 +53,30 @@ type SimpleInfo struct {
 	Delay     uint16 `json:"delay"`
 	Risk      uint8  `json:"risk"`
 	Count     uint32 `json:"count"`
+	Alive     uint32 `json:"alive"`
 }
 
 type Filter struct {
-	SubId         []uint16 `json:"sub_id"`
-	SubIdExclude  bool     `json:"sub_id_exclude"`
-	SpeedUpMore   uint32   `json:"speed_up_more"`
-	SpeedDownMore uint32   `json:"speed_down_more"`
-	Country       []string `json:"country"`
-	CountryExclude bool    `json:"country_exclude"`
-	DelayLessThan uint16   `json:"delay_less_than"`
-	AliveStatus   uint64   `json:"alive_status"`
-	RiskLessThan  uint8    `json:"risk_less_than"`
+	SubId          []uint16 `json:"sub_id"`
+	SubIdExclude   bool     `json:"sub_id_exclude"`
+	SpeedUpMore    uint32   `json:"speed_up_more"`
+	SpeedDownMore  uint32   `json:"speed_down_more"`
+	Country        []string `json:"country"`
+	CountryExclude bool     `json:"country_exclude"`
+	DelayLessThan  uint16   `json:"delay_less_than"`
+	AliveStatus    uint64   `json:"alive_status"`
+	RiskLessThan   uint8    `json:"risk_less_than"`
+
+	Protocol        []string `json:"protocol" description:"协议类型"`
+	ProtocolExclude bool     `json:"protocol_exclude"`
+	NameInclude     string   `json:"name_include" description:"节点名称包含(正则)"`
+	NameExclude     string   `json:"name_exclude" description:"节点名称排除(正则)"`
+	Tags            []string `json:"tags" description:"订阅标签，命中任意一个即匹配"`
+	TagsExclude     bool     `json:"tags_exclude"`
+	Port            string   `json:"port" description:"端口范围，如 443,8000-9000"`
+	TLS             *bool    `json:"tls" description:"是否启用TLS，为空不限制"`
+	Network         []string `json:"network" description:"传输方式 tcp/ws/grpc/h2/http"`
+	Expr            string   `json:"expr" description:"布尔表达式，如 protocol =~ \"vless|trojan\" && (tls || port == 443)"`
 }
 
 func (i *Info) SetAliveStatus(AliveStatus uint64, status bool) {
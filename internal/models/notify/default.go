@@ -2,7 +2,18 @@ package notify
 
 func DefaultTemplates() []Template {
 	return []Template{
-		// 	{"login_success", "登录成功", "{{.Username}}{{.Time}}{{.IP}}{{.UserAgent}}"},
-		// 	{"login_failed", "登录失败", "{{.Username}}{{.Time}}{{.IP}}{{.UserAgent}}"},
+		{"login_success", "用户 {{.Username}} 于 {{.Time}} 登录成功\nIP: {{.IP}}\nUser-Agent: {{.UserAgent}}"},
+		{"login_failed", "用户 {{.Username}} 于 {{.Time}} 登录失败: {{.Msg}}\nIP: {{.IP}}\nUser-Agent: {{.UserAgent}}"},
+		{"check_finished", "检测任务 {{.Name}}({{.Kind}}) 已完成，耗时 {{.Duration}} 毫秒\n{{.Msg}}"},
+		{"check_failed", "检测任务 {{.Name}}({{.Kind}}) 执行失败: {{.Msg}}"},
+		{"fetch_failed", "订阅 {{.Name}} 获取失败，累计失败 {{.Fail}} 次: {{.Msg}}"},
+		{"sub_disabled", "订阅 {{.Name}} 连续 {{.NodeNullCount}} 次未获取到节点，已自动禁用"},
+		{"sub_quality", `订阅 {{.Name}} 质量评分 {{.Score}} 低于阈值 {{.Threshold}}，入池率 {{printf "%.2f" .AdmissionRate}}，存活率 {{printf "%.2f" .AliveRate}}，已执行操作: {{.Action}}`},
+		{"pool_low", "节点池当前节点数量 {{.Count}}，低于阈值 {{.Threshold}}"},
+		{"share_expiring", "分享 {{.Name}} 将于 {{.Expires}} 过期，剩余 {{.Left}}"},
+		{"update_available", "{{.Component}} 发现新版本 {{.Latest}}，当前版本 {{.Current}}"},
+		{"task_check", "检测任务 {{.Name}}({{.Type}}) {{if .Failed}}执行失败{{else}}已完成{{end}}，耗时 {{.Duration}} 毫秒\n{{.Msg}}{{range $k, $v := .Extra}}\n{{$k}}: {{$v}}{{end}}"},
+		{"task_check_alive", "检测任务 {{.Name}} {{if .Failed}}执行失败: {{.Msg}}{{else}}已完成，耗时 {{.Duration}} 毫秒\n存活 {{.Extra.alive}}{{with .Prev}}(上次 {{.alive}}){{end}}，失效 {{.Extra.dead}}，平均延迟 {{.Extra.delay}}ms{{end}}"},
+		{"task_fetch", "订阅 {{.Name}} {{if .Failed}}获取失败: {{.Msg}}{{else}}获取完成，耗时 {{.Duration}} 毫秒，节点数量 {{.Extra.raw_count}}{{with .Prev}}(上次 {{.raw_count}}){{end}}{{end}}"},
 	}
 }
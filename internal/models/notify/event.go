@@ -0,0 +1,96 @@
+package notify
+
+// Event 通知事件，Type 决定使用的通知模板以及是否在 notify_operation 中开启
+type Event interface {
+	Type() uint16
+	Title() string
+}
+
+// CheckEvent 检测任务完成或失败
+type CheckEvent struct {
+	ID       uint16 `json:"id"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`     // 检测类型
+	Msg      string `json:"msg"`      // 检测结果或失败原因
+	Duration int64  `json:"duration"` // 运行时长(单位:毫秒)
+	Failed   bool   `json:"failed"`
+}
+
+func (e CheckEvent) Type() uint16 {
+	if e.Failed {
+		return TypeCheckFailed
+	}
+	return TypeCheckFinished
+}
+
+func (e CheckEvent) Title() string {
+	if e.Failed {
+		return "检测任务失败"
+	}
+	return "检测任务完成"
+}
+
+// FetchFailedEvent 订阅获取失败
+type FetchFailedEvent struct {
+	ID   uint16 `json:"id"`
+	Name string `json:"name"`
+	Msg  string `json:"msg"`
+	Fail uint16 `json:"fail"` // 累计失败次数
+}
+
+func (FetchFailedEvent) Type() uint16  { return TypeFetchFailed }
+func (FetchFailedEvent) Title() string { return "订阅获取失败" }
+
+// SubDisabledEvent 订阅连续获取不到节点被自动禁用
+type SubDisabledEvent struct {
+	ID            uint16 `json:"id"`
+	Name          string `json:"name"`
+	NodeNullCount uint16 `json:"node_null_count"`
+}
+
+func (SubDisabledEvent) Type() uint16  { return TypeSubDisabled }
+func (SubDisabledEvent) Title() string { return "订阅已自动禁用" }
+
+// SubQualityEvent 订阅质量评分低于阈值
+type SubQualityEvent struct {
+	ID            uint16  `json:"id"`
+	Name          string  `json:"name"`
+	Score         uint8   `json:"score"`
+	Threshold     int     `json:"threshold"`
+	AdmissionRate float64 `json:"admission_rate"` // 入池率
+	AliveRate     float64 `json:"alive_rate"`     // 存活率
+	Action        string  `json:"action"`         // 执行的操作 notify/deprioritize/disable
+}
+
+func (SubQualityEvent) Type() uint16  { return TypeSubQuality }
+func (SubQualityEvent) Title() string { return "订阅质量过低" }
+
+// PoolLowEvent 节点池中的节点数量低于阈值
+type PoolLowEvent struct {
+	Count     int `json:"count"`
+	Threshold int `json:"threshold"`
+}
+
+func (PoolLowEvent) Type() uint16  { return TypePoolLow }
+func (PoolLowEvent) Title() string { return "节点池数量过低" }
+
+// ShareExpiringEvent 分享链接即将过期
+type ShareExpiringEvent struct {
+	ID      uint16 `json:"id"`
+	Name    string `json:"name"`
+	Expires string `json:"expires"` // 过期时间
+	Left    string `json:"left"`    // 剩余时间
+}
+
+func (ShareExpiringEvent) Type() uint16  { return TypeShareExpiring }
+func (ShareExpiringEvent) Title() string { return "分享即将过期" }
+
+// UpdateEvent 组件有新版本
+type UpdateEvent struct {
+	Component string `json:"component"`
+	Current   string `json:"current"`
+	Latest    string `json:"latest"`
+}
+
+func (UpdateEvent) Type() uint16  { return TypeUpdateAvailable }
+func (UpdateEvent) Title() string { return "发现新版本" }
@@ -38,14 +38,53 @@ type Instance interface {
 	Send(title string, body *bytes.Buffer) error
 }
 
+// HTMLInstance 以 HTML 发送内容的渠道实现，通知模板中的变量会按 HTML 转义
+type HTMLInstance interface {
+	Instance
+	HTML() bool
+}
+
 const (
-	TypeLoginSuccess uint16 = 1 << 0 // 登录成功通知
-	TypeLoginFailed  uint16 = 1 << 1 // 登录失败通知
+	TypeLoginSuccess    uint16 = 1 << 0 // 登录成功通知
+	TypeLoginFailed     uint16 = 1 << 1 // 登录失败通知
+	TypeSubQuality      uint16 = 1 << 2 // 订阅质量过低通知
+	TypeCheckFinished   uint16 = 1 << 3 // 检测任务完成通知
+	TypeCheckFailed     uint16 = 1 << 4 // 检测任务失败通知
+	TypeFetchFailed     uint16 = 1 << 5 // 订阅获取失败通知
+	TypeSubDisabled     uint16 = 1 << 6 // 订阅自动禁用通知
+	TypePoolLow         uint16 = 1 << 7 // 节点池数量过低通知
+	TypeShareExpiring   uint16 = 1 << 8 // 分享即将过期通知
+	TypeUpdateAvailable uint16 = 1 << 9 // 新版本通知
 )
 
 var TypeMap = map[uint16]string{
-	TypeLoginSuccess: "login_success",
-	TypeLoginFailed:  "login_failed",
+	TypeLoginSuccess:    "login_success",
+	TypeLoginFailed:     "login_failed",
+	TypeSubQuality:      "sub_quality",
+	TypeCheckFinished:   "check_finished",
+	TypeCheckFailed:     "check_failed",
+	TypeFetchFailed:     "fetch_failed",
+	TypeSubDisabled:     "sub_disabled",
+	TypePoolLow:         "pool_low",
+	TypeShareExpiring:   "share_expiring",
+	TypeUpdateAvailable: "update_available",
+}
+
+// TypeInfo 通知类型与 notify_operation 中对应的位
+type TypeInfo struct {
+	Bit  uint16 `json:"bit"`
+	Type string `json:"type"`
+}
+
+// Types 按位从小到大返回所有通知类型
+func Types() []TypeInfo {
+	types := make([]TypeInfo, 0, len(TypeMap))
+	for bit := uint16(1); bit != 0; bit <<= 1 {
+		if t, ok := TypeMap[bit]; ok {
+			types = append(types, TypeInfo{Bit: bit, Type: t})
+		}
+	}
+	return types
 }
 
 func (c *Request) GenData(id uint16) Data {
@@ -0,0 +1,58 @@
+package notify
+
+import "fmt"
+
+// 任务结果的通知条件
+const (
+	TaskNotifyAlways  = "always"  // 每次运行后通知
+	TaskNotifyFailure = "failure" // 仅失败时通知
+	TaskNotifyChange  = "change"  // 失败或结果统计变化超过阈值时通知
+)
+
+// ValidateTaskNotify 检查任务的通知条件与变化阈值
+func ValidateTaskNotify(mode string, change int) error {
+	switch mode {
+	case "", TaskNotifyAlways, TaskNotifyFailure, TaskNotifyChange:
+	default:
+		return fmt.Errorf("invalid notify mode: %s", mode)
+	}
+	if change < 0 || change > 100 {
+		return fmt.Errorf("notify change must be between 0 and 100")
+	}
+	return nil
+}
+
+// TaskResult 检测与获取任务结果通知的模板数据
+type TaskResult struct {
+	ID       uint16         `json:"id"`
+	Name     string         `json:"name"`
+	Task     string         `json:"task"` // check 或 fetch
+	Type     string         `json:"type"` // 检测类型，获取任务为空
+	Msg      string         `json:"msg"`
+	Duration int64          `json:"duration"` // 运行时长(单位:毫秒)
+	Failed   bool           `json:"failed"`
+	Extra    map[string]any `json:"extra"`    // 本次统计
+	Prev     map[string]any `json:"prev"`     // 上一次统计，首次运行为空
+	PrevMsg  string         `json:"prev_msg"` // 上一次的消息
+}
+
+// Title 通知标题
+func (r *TaskResult) Title() string {
+	task := "检测任务"
+	if r.Task == "fetch" {
+		task = "订阅获取"
+	}
+	if r.Failed {
+		return task + "失败: " + r.Name
+	}
+	return task + "完成: " + r.Name
+}
+
+// Templates 依次尝试的模板类型，检测任务优先使用对应检测类型的模板
+func (r *TaskResult) Templates() []string {
+	base := "task_" + r.Task
+	if r.Type == "" {
+		return []string{base}
+	}
+	return []string{base + "_" + r.Type, base}
+}
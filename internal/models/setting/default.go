@@ -31,14 +31,90 @@ func DefaultSetting() []Setting {
 			Key:   SUBCONVERTER_URL_PROXY,
 			Value: "false",
 		},
+		{
+			Key:   SUBCONVERTER_ADD_EMOJI,
+			Value: "false",
+		},
+		{
+			Key:   SUBCONVERTER_REMOVE_OLD_EMOJI,
+			Value: "false",
+		},
+		{
+			Key:   SUBCONVERTER_RULE_BASE,
+			Value: "base/all_base.tpl",
+		},
+		{
+			Key:   SUBCONVERTER_EXTERNAL_CONFIG,
+			Value: "",
+		},
+		{
+			Key:   SUBCONVERTER_CLASH_HTTP_PORT,
+			Value: "7890",
+		},
+		{
+			Key:   SUBCONVERTER_CLASH_SOCKS_PORT,
+			Value: "7891",
+		},
+		{
+			Key:   SUBCONVERTER_SINGBOX_MIXED_PORT,
+			Value: "2080",
+		},
+		{
+			Key:   SUBCONVERTER_ALLOW_LAN,
+			Value: "true",
+		},
+		{
+			Key:   SUBCONVERTER_LOG_LEVEL,
+			Value: "debug",
+		},
+		{
+			Key:   SUBCONVERTER_MAX_THREAD,
+			Value: "100",
+		},
+		{
+			Key:   SUBCONVERTER_CACHE_CONFIG,
+			Value: "86400",
+		},
+		{
+			Key:   SUBCONVERTER_CACHE_RULESET,
+			Value: "86400",
+		},
 		{
 			Key:   SUB_DISABLE_AUTO,
 			Value: "0",
 		},
+		{
+			Key:   SUB_HISTORY_LIMIT,
+			Value: "30",
+		},
+		{
+			Key:   SUB_QUALITY_MIN_SCORE,
+			Value: "0",
+		},
+		{
+			Key:   SUB_QUALITY_MIN_FETCH,
+			Value: "3",
+		},
+		{
+			Key:   SUB_QUALITY_ACTION,
+			Value: "notify",
+		},
+		{
+			Key:   SHARE_ACCESS_LOG_LIMIT,
+			Value: "1000",
+		},
+		{
+			Key:   SHARE_CACHE_TTL,
+			Value: "600",
+		},
 		{
 			Key:   NODE_POOL_SIZE,
 			Value: "1000",
 		},
+		{
+			Key:   NODE_POOL_MIN,
+			Value: "0",
+		},
 		{
 			Key:   NODE_TEST_URL,
 			Value: "https://www.gstatic.com/generate_204",
@@ -59,6 +135,26 @@ func DefaultSetting() []Setting {
 			Key:   NODE_PROTOCOL_FILTER,
 			Value: "",
 		},
+		{
+			Key:   NODE_NAME_INCLUDE,
+			Value: "",
+		},
+		{
+			Key:   NODE_NAME_EXCLUDE,
+			Value: "",
+		},
+		{
+			Key:   NODE_SERVER_INCLUDE,
+			Value: "",
+		},
+		{
+			Key:   NODE_SERVER_EXCLUDE,
+			Value: "",
+		},
+		{
+			Key:   NODE_JUNK_FILTER,
+			Value: "false",
+		},
 		{
 			Key:   TASK_MAX_THREAD,
 			Value: "200",
@@ -16,9 +16,31 @@ const (
 	SUBCONVERTER_URL       = "subconverter_url"
 	SUBCONVERTER_URL_PROXY = "subconverter_url_proxy"
 
-	SUB_DISABLE_AUTO = "sub_disable_auto"
+	SUBCONVERTER_ADD_EMOJI          = "subconverter_add_emoji"
+	SUBCONVERTER_REMOVE_OLD_EMOJI   = "subconverter_remove_old_emoji"
+	SUBCONVERTER_RULE_BASE          = "subconverter_rule_base"
+	SUBCONVERTER_EXTERNAL_CONFIG    = "subconverter_external_config"
+	SUBCONVERTER_CLASH_HTTP_PORT    = "subconverter_clash_http_port"
+	SUBCONVERTER_CLASH_SOCKS_PORT   = "subconverter_clash_socks_port"
+	SUBCONVERTER_SINGBOX_MIXED_PORT = "subconverter_singbox_mixed_port"
+	SUBCONVERTER_ALLOW_LAN          = "subconverter_allow_lan"
+	SUBCONVERTER_LOG_LEVEL          = "subconverter_log_level"
+	SUBCONVERTER_MAX_THREAD         = "subconverter_max_thread"
+	SUBCONVERTER_CACHE_CONFIG       = "subconverter_cache_config"
+	SUBCONVERTER_CACHE_RULESET      = "subconverter_cache_ruleset"
+
+	SUB_DISABLE_AUTO  = "sub_disable_auto"
+	SUB_HISTORY_LIMIT = "sub_history_limit"
+
+	SUB_QUALITY_MIN_SCORE = "sub_quality_min_score"
+	SUB_QUALITY_MIN_FETCH = "sub_quality_min_fetch"
+	SUB_QUALITY_ACTION    = "sub_quality_action"
+
+	SHARE_ACCESS_LOG_LIMIT = "share_access_log_limit"
+	SHARE_CACHE_TTL        = "share_cache_ttl"
 
 	NODE_POOL_SIZE    = "node_pool_size"
+	NODE_POOL_MIN     = "node_pool_min"
 	NODE_TEST_URL     = "node_test_url"
 	NODE_TEST_TIMEOUT = "node_test_timeout"
 
@@ -26,6 +48,12 @@ const (
 	NODE_PROTOCOL_FILTER_MODE   = "node_protocol_filter_mode"
 	NODE_PROTOCOL_FILTER        = "node_protocol_filter"
 
+	NODE_NAME_INCLUDE   = "node_name_include"
+	NODE_NAME_EXCLUDE   = "node_name_exclude"
+	NODE_SERVER_INCLUDE = "node_server_include"
+	NODE_SERVER_EXCLUDE = "node_server_exclude"
+	NODE_JUNK_FILTER    = "node_junk_filter"
+
 	TASK_MAX_THREAD  = "task_max_thread"
 	TASK_MAX_TIMEOUT = "task_max_timeout"
 	TASK_MAX_RETRY   = "task_max_retry"
@@ -0,0 +1,31 @@
+package share
+
+// AccessLog 分享链接访问记录
+type AccessLog struct {
+	ID         uint32 `db:"id" json:"id"`
+	ShareID    uint16 `db:"share_id" json:"share_id"`
+	AccessedAt int64  `db:"accessed_at" json:"accessed_at" description:"访问时间(unix秒)"`
+	IP         string `db:"ip" json:"ip"`
+	UserAgent  string `db:"user_agent" json:"user_agent"`
+	Format     string `db:"format" json:"format" description:"输出格式"`
+	NodeCount  uint32 `db:"node_count" json:"node_count"`
+	Bytes      uint32 `db:"bytes" json:"bytes" description:"响应大小"`
+}
+
+// AccessClient 按IP聚合的访问客户端
+type AccessClient struct {
+	IP            string `json:"ip"`
+	Count         uint32 `json:"count" description:"访问次数"`
+	UserAgents    uint32 `json:"user_agents" description:"不同UA数量"`
+	LastUserAgent string `json:"last_user_agent"`
+	FirstSeen     int64  `json:"first_seen"`
+	LastSeen      int64  `json:"last_seen"`
+}
+
+// AccessStats 分享链接访问统计，不同IP过多通常意味着链接已泄露
+type AccessStats struct {
+	Total      uint32         `json:"total" description:"记录内的访问次数"`
+	DistinctIP uint32         `json:"distinct_ip"`
+	DistinctUA uint32         `json:"distinct_ua"`
+	Clients    []AccessClient `json:"clients"`
+}
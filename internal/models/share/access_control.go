@@ -0,0 +1,47 @@
+package share
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// AccessConfig 分享链接访问控制
+type AccessConfig struct {
+	AllowCIDR      []string `json:"allow_cidr" description:"允许访问的IP段，为空不限制"`
+	MaxClientIP    uint16   `json:"max_client_ip" description:"最多允许的不同IP数量，0为不限制"`
+	MaxClientUA    uint16   `json:"max_client_ua" description:"最多允许的不同UA数量，0为不限制"`
+	RateLimit      uint16   `json:"rate_limit" description:"每个IP每分钟最大请求数，0为不限制"`
+	RotateInterval uint32   `json:"rotate_interval" description:"自动更换token的间隔(小时)，0为不更换"`
+	RotateGrace    uint32   `json:"rotate_grace" description:"更换后旧token继续有效的时间(分钟)"`
+}
+
+func (a *AccessConfig) Validate() error {
+	_, err := a.Prefixes()
+	return err
+}
+
+// Prefixes 解析允许的IP段，单个IP视为/32或/128
+func (a *AccessConfig) Prefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(a.AllowCIDR))
+	for _, cidr := range a.AllowCIDR {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allow_cidr %q", cidr)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow_cidr %q", cidr)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,46 @@
+package share
+
+import (
+	"fmt"
+	"slices"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+const (
+	GroupTypeSelect      = "select"
+	GroupTypeURLTest     = "url-test"
+	GroupTypeFallback    = "fallback"
+	GroupTypeLoadBalance = "load-balance"
+)
+
+// GroupConfig 分享中的一个分组，每个分组独立筛选节点并生成一个代理组
+type GroupConfig struct {
+	Name   string           `json:"name"`
+	Type   string           `json:"type" description:"代理组类型 select/url-test/fallback/load-balance，默认select"`
+	Filter nodeModel.Filter `json:"filter"`
+	Rename string           `json:"rename" description:"重命名模板，为空时使用分享的重命名模板"`
+	Order  OrderConfig      `json:"order"`
+}
+
+// GroupType 代理组类型，未设置时为 select
+func (g *GroupConfig) GroupType() string {
+	if g.Type == "" {
+		return GroupTypeSelect
+	}
+	return g.Type
+}
+
+// Validate 检查分组名称、类型与排序条件，过滤与重命名条件由调用方检查
+func (g *GroupConfig) Validate() error {
+	if g.Name == "" {
+		return fmt.Errorf("group name is required")
+	}
+	if !slices.Contains([]string{GroupTypeSelect, GroupTypeURLTest, GroupTypeFallback, GroupTypeLoadBalance}, g.GroupType()) {
+		return fmt.Errorf("group %s: invalid type: %s", g.Name, g.Type)
+	}
+	if err := g.Order.Validate(); err != nil {
+		return fmt.Errorf("group %s: %w", g.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package share
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	SortDelay   = "delay"
+	SortSpeed   = "speed"
+	SortScore   = "score"
+	SortCountry = "country"
+
+	GroupByCountry = "country"
+	GroupBySub     = "sub"
+)
+
+// OrderConfig 节点排序与数量限制
+type OrderConfig struct {
+	Sort       []string `json:"sort" description:"排序字段 delay/speed/score/country，依次比较，均为优者在前"`
+	Reverse    bool     `json:"reverse" description:"反转排序结果"`
+	Limit      uint16   `json:"limit" description:"节点总数上限，0为不限制"`
+	GroupBy    string   `json:"group_by" description:"分组限额依据 country/sub"`
+	GroupLimit uint16   `json:"group_limit" description:"每组节点数上限，0为不限制"`
+}
+
+func (o *OrderConfig) Validate() error {
+	for _, key := range o.Sort {
+		if !slices.Contains([]string{SortDelay, SortSpeed, SortScore, SortCountry}, key) {
+			return fmt.Errorf("invalid sort key: %s", key)
+		}
+	}
+	if o.GroupBy != "" && o.GroupBy != GroupByCountry && o.GroupBy != GroupBySub {
+		return fmt.Errorf("invalid group_by: %s", o.GroupBy)
+	}
+	if o.GroupLimit > 0 && o.GroupBy == "" {
+		return fmt.Errorf("group_limit requires group_by")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package share
+
+// Preview 分享配置的预览结果
+type Preview struct {
+	Total     int            `json:"total" description:"匹配的节点数"`
+	Nodes     []PreviewNode  `json:"nodes"`
+	Countries []PreviewGroup `json:"countries" description:"按国家统计"`
+	Subs      []PreviewGroup `json:"subs" description:"按订阅统计"`
+	Groups    []PreviewGroup `json:"groups" description:"按分享分组统计"`
+	Errors    []string       `json:"errors" description:"过滤、重命名或模板错误"`
+	Output    string         `json:"output,omitempty" description:"使用规则模板渲染的内容"`
+}
+
+// PreviewNode 预览中的单个节点
+type PreviewNode struct {
+	Name       string `json:"name" description:"重命名后的名称"`
+	OriginName string `json:"origin_name" description:"订阅提供的原始名称"`
+	Protocol   string `json:"protocol"`
+	Server     string `json:"server"`
+	Port       int    `json:"port"`
+	Country    string `json:"country"`
+	SubName    string `json:"sub_name"`
+	Delay      uint32 `json:"delay"`
+	SpeedDown  uint32 `json:"speed_down"`
+	Score      uint32 `json:"score"`
+}
+
+// PreviewGroup 预览中的分组统计
+type PreviewGroup struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
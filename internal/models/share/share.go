@@ -15,13 +15,32 @@ type Data struct {
 	AccessCount    uint32 `db:"access_count" json:"access_count"`
 	MaxAccessCount uint32 `db:"max_access_count" json:"max_access_count"`
 	Expires        uint64 `db:"expires" json:"expires"`
+
+	Access           string `db:"access" json:"access"`
+	PrevToken        string `db:"prev_token" json:"prev_token"`
+	PrevTokenExpires uint64 `db:"prev_token_expires" json:"prev_token_expires"`
+	TokenRotatedAt   uint64 `db:"token_rotated_at" json:"token_rotated_at"`
 }
 
 type GenConfig struct {
 	Filter       nodeModel.Filter   `json:"filter"`
 	Rename       string             `json:"rename"`
+	Order        OrderConfig        `json:"order"`
 	Proxy        bool               `json:"proxy"`
 	SubConverter SubConverterConfig `json:"sub_converter"`
+	Template     TemplateConfig     `json:"template"`
+	Groups       []GroupConfig      `json:"groups" description:"分组，设置后节点由各分组筛选并生成对应的代理组，分享的过滤条件对每个分组生效，排序只支持节点总数上限"`
+	Narrow       *nodeModel.Filter  `json:"narrow,omitempty" description:"附加过滤条件，节点需同时满足，签名链接使用"`
+	Profile      ProfileConfig      `json:"profile"`
+}
+
+// ProfileConfig 返回给客户端的订阅信息响应头
+type ProfileConfig struct {
+	FileName       string `json:"file_name" description:"客户端显示的配置名称，为空使用分享名称"`
+	UpdateInterval uint16 `json:"update_interval" description:"客户端自动更新间隔(小时)，0为不设置"`
+	Upload         uint64 `json:"upload" description:"已用上传流量(字节)"`
+	Download       uint64 `json:"download" description:"已用下载流量(字节)"`
+	Total          uint64 `json:"total" description:"总流量(字节)，为0时按访问次数折算"`
 }
 
 type SubConverterConfig struct {
@@ -30,23 +49,28 @@ type SubConverterConfig struct {
 }
 
 type Request struct {
-	Enable         bool      `json:"enable"`
-	Name           string    `json:"name"`
-	Token          string    `json:"token"`
-	Gen            GenConfig `json:"gen"`
-	MaxAccessCount uint32    `json:"max_access_count"`
-	Expires        uint64    `json:"expires"`
+	Enable         bool         `json:"enable"`
+	Name           string       `json:"name"`
+	Token          string       `json:"token"`
+	Gen            GenConfig    `json:"gen"`
+	Access         AccessConfig `json:"access"`
+	MaxAccessCount uint32       `json:"max_access_count"`
+	Expires        uint64       `json:"expires"`
 }
 
 type Response struct {
-	ID             uint16    `json:"id"`
-	Name           string    `json:"name"`
-	Enable         bool      `json:"enable"`
-	AccessCount    uint32    `json:"access_count"`
-	MaxAccessCount uint32    `json:"max_access_count"`
-	Expires        uint64    `json:"expires"`
-	Token          string    `json:"token"`
-	Gen            GenConfig `json:"gen"`
+	ID             uint16       `json:"id"`
+	Name           string       `json:"name"`
+	Enable         bool         `json:"enable"`
+	AccessCount    uint32       `json:"access_count"`
+	MaxAccessCount uint32       `json:"max_access_count"`
+	Expires        uint64       `json:"expires"`
+	Token          string       `json:"token"`
+	Gen            GenConfig    `json:"gen"`
+	Access         AccessConfig `json:"access"`
+
+	PrevTokenExpires uint64 `json:"prev_token_expires" description:"旧token失效时间"`
+	TokenRotatedAt   uint64 `json:"token_rotated_at" description:"token最后更换时间"`
 }
 
 type UpdateAccessCountDB struct {
@@ -59,6 +83,10 @@ func (r *Request) GenData() Data {
 	if err != nil {
 		return Data{}
 	}
+	accessBytes, err := json.Marshal(r.Access)
+	if err != nil {
+		return Data{}
+	}
 	return Data{
 		Enable:         r.Enable,
 		Name:           r.Name,
@@ -66,6 +94,7 @@ func (r *Request) GenData() Data {
 		MaxAccessCount: r.MaxAccessCount,
 		Expires:        r.Expires,
 		Gen:            string(configBytes),
+		Access:         string(accessBytes),
 	}
 }
 
@@ -75,13 +104,33 @@ func (r *Data) GenResponse() Response {
 		return Response{}
 	}
 	return Response{
-		ID:             r.ID,
-		Name:           r.Name,
-		Enable:         r.Enable,
-		AccessCount:    r.AccessCount,
-		MaxAccessCount: r.MaxAccessCount,
-		Expires:        r.Expires,
-		Token:          r.Token,
-		Gen:            config,
+		Access:           r.AccessConfig(),
+		PrevTokenExpires: r.PrevTokenExpires,
+		TokenRotatedAt:   r.TokenRotatedAt,
+		ID:               r.ID,
+		Name:             r.Name,
+		Enable:           r.Enable,
+		AccessCount:      r.AccessCount,
+		MaxAccessCount:   r.MaxAccessCount,
+		Expires:          r.Expires,
+		Token:            r.Token,
+		Gen:              config,
+	}
+}
+
+// AccessConfig 解析访问控制配置
+func (r *Data) AccessConfig() AccessConfig {
+	var access AccessConfig
+	if r.Access != "" {
+		json.Unmarshal([]byte(r.Access), &access)
+	}
+	return access
+}
+
+// MatchToken 判断token是否有效，更换后的旧token在宽限期内仍然有效
+func (r *Data) MatchToken(token string, now uint64) bool {
+	if r.Token == token {
+		return true
 	}
+	return r.PrevToken != "" && r.PrevToken == token && r.PrevTokenExpires > now
 }
@@ -0,0 +1,28 @@
+package share
+
+import nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+
+// SignedClaims 签名分享链接携带的参数，不保存到数据库
+//
+// 签名同时绑定分享当前的 token，更换 token 后所有签名链接失效
+type SignedClaims struct {
+	ShareID uint16            `json:"s"`
+	Expires int64             `json:"e"`
+	Limit   uint16            `json:"l,omitempty"`
+	Filter  *nodeModel.Filter `json:"f,omitempty"`
+}
+
+// SignRequest 生成签名分享链接的参数
+type SignRequest struct {
+	TTL    uint32            `json:"ttl" description:"有效期(秒)，0为24小时，不超过分享本身的过期时间"`
+	Limit  uint16            `json:"limit" description:"输出的节点总数上限，配置了分组时为各分组的并集，0为不限制"`
+	Filter *nodeModel.Filter `json:"filter" description:"附加过滤条件，只能在分享的节点中进一步筛选"`
+}
+
+// SignResponse 签名分享链接
+type SignResponse struct {
+	Token   string `json:"token"`
+	Expires int64  `json:"expires" description:"过期时间(unix秒)"`
+	NodeUrl string `json:"node_url" description:"节点链接路径"`
+	SubUrl  string `json:"sub_url" description:"带规则订阅链接路径"`
+}
@@ -0,0 +1,125 @@
+package share
+
+import "time"
+
+const (
+	TemplateTypeClash   = "clash"
+	TemplateTypeSingBox = "singbox"
+)
+
+// Template 规则/分组模板
+type Template struct {
+	ID          uint16    `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Type        string    `db:"type" json:"type" example:"clash" enums:"clash,singbox"`
+	Description string    `db:"description" json:"description"`
+	Template    string    `db:"template" json:"template"`
+	Version     uint32    `db:"version" json:"version"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// TemplateVersion 模板的历史版本
+type TemplateVersion struct {
+	TemplateID uint16    `db:"template_id" json:"template_id"`
+	Version    uint32    `db:"version" json:"version"`
+	Template   string    `db:"template" json:"template"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type TemplateRequest struct {
+	Name        string `json:"name"`
+	Type        string `json:"type" example:"clash" enums:"clash,singbox"`
+	Description string `json:"description"`
+	Template    string `json:"template"`
+}
+
+// TemplateConfig 分享使用的模板，为0时使用 subconverter 生成
+type TemplateConfig struct {
+	Clash   uint16 `json:"clash" description:"Clash 模板ID"`
+	SingBox uint16 `json:"singbox" description:"sing-box 模板ID"`
+}
+
+// Get 根据输出格式获取模板ID
+func (c TemplateConfig) Get(target string) uint16 {
+	switch target {
+	case TemplateTypeClash:
+		return c.Clash
+	case TemplateTypeSingBox:
+		return c.SingBox
+	}
+	return 0
+}
+
+func (r *TemplateRequest) GenData() Template {
+	return Template{
+		Name:        r.Name,
+		Type:        r.Type,
+		Description: r.Description,
+		Template:    r.Template,
+	}
+}
+
+// DefaultTemplates 初始化时创建的示例模板
+func DefaultTemplates() []Template {
+	return []Template{
+		{
+			Name:        "默认 Clash",
+			Type:        TemplateTypeClash,
+			Description: "按分享分组、国家、订阅自动分组，附带最快节点组",
+			Template: `mixed-port: 7890
+allow-lan: false
+mode: rule
+log-level: info
+dns:
+  enable: true
+  enhanced-mode: fake-ip
+  nameserver:
+    - https://223.5.5.5/dns-query
+    - https://1.12.12.12/dns-query
+proxy-groups:
+  - {name: 节点选择, type: select, proxies: {{json (concat "自动选择" "最快节点" (names .Groups) (names .Countries) (names .Subs))}}}
+  - {name: 自动选择, type: url-test, url: http://www.gstatic.com/generate_204, interval: 300, proxies: {{json .All}}}
+  - {name: 最快节点, type: url-test, url: http://www.gstatic.com/generate_204, interval: 300, proxies: {{json (.Fastest 10)}}}
+{{clashGroups "" .Groups}}{{clashGroups "url-test" .Countries}}{{clashGroups "select" .Subs}}rules:
+  - GEOSITE,private,DIRECT
+  - GEOIP,private,DIRECT,no-resolve
+  - GEOSITE,cn,DIRECT
+  - GEOIP,CN,DIRECT
+  - MATCH,节点选择
+`,
+		},
+		{
+			Name:        "默认 sing-box",
+			Type:        TemplateTypeSingBox,
+			Description: "按分享分组、国家自动分组，附带最快节点组",
+			Template: `{
+  "log": {"level": "info"},
+  "dns": {
+    "servers": [{"tag": "remote", "address": "https://1.1.1.1/dns-query", "detour": "select"}, {"tag": "local", "address": "https://223.5.5.5/dns-query", "detour": "direct"}],
+    "rules": [{"rule_set": "geosite-cn", "server": "local"}]
+  },
+  "inbounds": [{"type": "mixed", "listen": "127.0.0.1", "listen_port": 7890}],
+  "outbounds": [
+    {"type": "selector", "tag": "select", "outbounds": {{json (concat "auto" "fastest" (names .Groups) (names .Countries))}}},
+    {"type": "urltest", "tag": "auto", "outbounds": {{json .All}}},
+    {"type": "urltest", "tag": "fastest", "outbounds": {{json (.Fastest 10)}}},
+    {{singboxGroups "" .Groups}}{{singboxGroups "urltest" .Countries}}
+    {"type": "direct", "tag": "direct"}
+  ],
+  "route": {
+    "rule_set": [
+      {"tag": "geosite-cn", "type": "remote", "format": "binary", "url": "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs", "download_detour": "select"},
+      {"tag": "geoip-cn", "type": "remote", "format": "binary", "url": "https://raw.githubusercontent.com/SagerNet/sing-geoip/rule-set/geoip-cn.srs", "download_detour": "select"}
+    ],
+    "rules": [
+      {"ip_is_private": true, "outbound": "direct"},
+      {"rule_set": ["geosite-cn", "geoip-cn"], "outbound": "direct"}
+    ],
+    "final": "select",
+    "auto_detect_interface": true
+  }
+}
+`,
+		},
+	}
+}
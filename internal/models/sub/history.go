@@ -0,0 +1,60 @@
+package sub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type History struct {
+	ID            uint32    `db:"id" json:"id"`
+	SubID         uint16    `db:"sub_id" json:"sub_id"`
+	FetchedAt     time.Time `db:"fetched_at" json:"fetched_at"`
+	Duration      uint16    `db:"duration" json:"duration"`
+	StatusCode    int       `db:"status_code" json:"status_code"` // subconverter 返回的状态码，不是订阅商的状态码
+	RawCount      uint32    `db:"raw_count" json:"raw_count"`
+	FilteredCount uint32    `db:"filtered_count" json:"filtered_count"`
+	AdmittedCount uint32    `db:"admitted_count" json:"admitted_count"`
+	Error         string    `db:"error" json:"error"`
+	Added         string    `db:"added" json:"added"`
+	Removed       string    `db:"removed" json:"removed"`
+	Nodes         string    `db:"nodes" json:"-"`
+
+	Snapshot []HistoryNode `db:"-" json:"-"`
+}
+
+type HistoryNode struct {
+	Key  string `json:"key" description:"节点唯一标识"`
+	Name string `json:"name" description:"节点名称"`
+}
+
+type HistoryResponse struct {
+	ID            uint32        `json:"id"`
+	FetchedAt     time.Time     `json:"fetched_at" description:"获取时间"`
+	Duration      uint16        `json:"duration" description:"运行时长(单位:毫秒)"`
+	StatusCode    int           `json:"status_code" description:"subconverter 返回的HTTP状态码，不是订阅商的状态码"`
+	RawCount      uint32        `json:"raw_count" description:"原始节点数量"`
+	FilteredCount uint32        `json:"filtered_count" description:"过滤后节点数量"`
+	AdmittedCount uint32        `json:"admitted_count" description:"提交检测的新节点数量"`
+	Error         string        `json:"error" description:"错误信息"`
+	Added         []HistoryNode `json:"added" description:"相比上次新增的节点"`
+	Removed       []HistoryNode `json:"removed" description:"相比上次消失的节点"`
+}
+
+func (h *History) GenResponse() HistoryResponse {
+	added := make([]HistoryNode, 0)
+	json.Unmarshal([]byte(h.Added), &added)
+	removed := make([]HistoryNode, 0)
+	json.Unmarshal([]byte(h.Removed), &removed)
+	return HistoryResponse{
+		ID:            h.ID,
+		FetchedAt:     h.FetchedAt,
+		Duration:      h.Duration,
+		StatusCode:    h.StatusCode,
+		RawCount:      h.RawCount,
+		FilteredCount: h.FilteredCount,
+		AdmittedCount: h.AdmittedCount,
+		Error:         h.Error,
+		Added:         added,
+		Removed:       removed,
+	}
+}
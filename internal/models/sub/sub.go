@@ -2,9 +2,12 @@ package sub
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"time"
 
 	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
 )
 
 type Data struct {
@@ -26,6 +29,19 @@ type Config struct {
 	ProtocolFilterEnable bool     `json:"protocol_filter_enable"`
 	ProtocolFilterMode   bool     `json:"protocol_filter_mode"`
 	ProtocolFilter       []string `json:"protocol_filter"`
+	NameInclude          string   `json:"name_include" description:"节点名称包含正则"`
+	NameExclude          string   `json:"name_exclude" description:"节点名称排除正则"`
+	ServerInclude        string   `json:"server_include" description:"节点地址包含正则"`
+	ServerExclude        string   `json:"server_exclude" description:"节点地址排除正则"`
+
+	PoolProxy  bool             `json:"pool_proxy" description:"通过节点池中的节点获取订阅"`
+	PoolFilter nodeModel.Filter `json:"pool_filter" description:"可用于获取订阅的节点筛选条件"`
+	PoolRetry  int              `json:"pool_retry" description:"最多尝试的节点数量"`
+
+	Notify        bool   `json:"notify" description:"是否通知获取结果"`
+	NotifyChannel int    `json:"notify_channel" description:"通知渠道，为0使用系统通知渠道"`
+	NotifyMode    string `json:"notify_mode" description:"通知条件 always:每次 failure:仅失败 change:失败或节点数量变化"`
+	NotifyChange  int    `json:"notify_change" description:"节点数量变化通知阈值 百分比"`
 }
 
 type Result struct {
@@ -38,6 +54,22 @@ type Result struct {
 	Duration      uint16    `json:"duration,omitempty" description:"运行时长(单位:毫秒)"`
 }
 
+type Quality struct {
+	SubID            uint16  `json:"sub_id" description:"订阅ID"`
+	FetchCount       uint32  `json:"fetch_count" description:"参与统计的获取次数"`
+	FetchSuccessRate float64 `json:"fetch_success_rate" description:"获取成功率"`
+	FilteredCount    uint32  `json:"filtered_count" description:"最近一次过滤后节点数量"`
+	PoolCount        uint32  `json:"pool_count" description:"节点池中来自该订阅的节点数量"`
+	AliveCount       uint32  `json:"alive_count" description:"节点池中存活的节点数量"`
+	AdmissionRate    float64 `json:"admission_rate" description:"入池率"`
+	AliveRate        float64 `json:"alive_rate" description:"存活率"`
+	SpeedDown        uint32  `json:"speed_down" description:"平均下行速度"`
+	Delay            uint16  `json:"delay" description:"平均延迟"`
+	Contribution     float64 `json:"contribution" description:"对节点池的贡献占比"`
+	Score            uint8   `json:"score" description:"质量评分(0-100)"`
+	LowPriority      bool    `json:"low_priority" description:"是否已降低优先级"`
+}
+
 type Request struct {
 	Name     string   `json:"name" description:"订阅任务名称"`
 	Tags     []string `json:"tags" description:"订阅标签"`
@@ -46,6 +78,17 @@ type Request struct {
 	Config   Config   `json:"config"`
 }
 
+type Export struct {
+	Version    int       `json:"version" description:"导出格式版本"`
+	ExportedAt time.Time `json:"exported_at" description:"导出时间"`
+	Subs       []Request `json:"subs" description:"订阅列表"`
+}
+
+type ImportResult struct {
+	Created []Response `json:"created" description:"新建的订阅"`
+	Skipped []string   `json:"skipped" description:"已存在而跳过的订阅链接"`
+}
+
 type Response struct {
 	ID        uint16               `json:"id" description:"订阅任务ID"`
 	Name      string               `json:"name" description:"订阅任务名称"`
@@ -60,6 +103,19 @@ type Response struct {
 	UpdatedAt time.Time            `json:"updated_at" description:"更新时间"`
 }
 
+// Validate 检查配置中的正则表达式是否合法
+func (c *Config) Validate() error {
+	for _, expr := range []string{c.NameInclude, c.NameExclude, c.ServerInclude, c.ServerExclude} {
+		if expr == "" {
+			continue
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+	}
+	return notifyModel.ValidateTaskNotify(c.NotifyMode, c.NotifyChange)
+}
+
 func (c *Request) GenData(id uint16) Data {
 	configBytes, err := json.Marshal(c.Config)
 	if err != nil {
@@ -6,6 +6,22 @@ type HealthResponse struct {
 	Timestamp string `json:"timestamp" example:"2024-01-01T12:00:00"` // 检查时间
 	Version   string `json:"version" example:"1.0.0"`                 // 版本信息
 	Database  string `json:"database" example:"connected"`            // 数据库状态
+
+	SubConverter SubConverterStatus `json:"subconverter"` // subconverter 状态
+}
+
+// SubConverterStatus subconverter 进程状态
+type SubConverterStatus struct {
+	Running   bool   `json:"running"`                     // 进程是否在运行，外部 subconverter 为最近一次检查是否可用
+	Healthy   bool   `json:"healthy"`                     // 最近一次 /version 检查是否成功
+	Pid       int    `json:"pid"`                         // 进程ID
+	Version   string `json:"version" example:"v0.9.0"`    // 版本
+	Restarts  uint32 `json:"restarts"`                    // 异常重启次数
+	StartedAt string `json:"started_at"`                  // 最近一次启动时间
+	LastCheck string `json:"last_check"`                  // 最近一次健康检查时间
+	LastError string `json:"last_error" example:"exit 1"` // 最近一次错误
+	Remote    bool   `json:"remote"`                      // 是否为外部 subconverter
+	Url       string `json:"url,omitempty"`               // 外部 subconverter 地址
 }
 
 // 系统信息结构
@@ -37,6 +37,11 @@ func (e *Email) Init() error {
 	return nil
 }
 
+// HTML 邮件以 text/html 发送
+func (e *Email) HTML() bool {
+	return true
+}
+
 func (e *Email) Send(title string, body *bytes.Buffer) error {
 	if body == nil {
 		return fmt.Errorf("email body is nil")
@@ -2,20 +2,219 @@ package channel
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/bestruirui/bestsub/internal/core/mihomo"
 	"github.com/bestruirui/bestsub/internal/modules/register"
 )
 
+const (
+	webhookJSON  = "json"
+	webhookForm  = "form"
+	webhookPlain = "plain"
+)
+
+var webhookBodies = map[string]string{
+	webhookJSON:  `{"title":"{{.Title}}","content":"{{.Content}}","time":"{{.Time}}"}`,
+	webhookForm:  `title={{.Title}}&content={{.Content}}&time={{.Time}}`,
+	webhookPlain: "{{.Title}}\n\n{{.Content}}",
+}
+
+var webhookContentTypes = map[string]string{
+	webhookJSON:  "application/json",
+	webhookForm:  "application/x-www-form-urlencoded",
+	webhookPlain: "text/plain; charset=utf-8",
+}
+
 type WebHook struct {
-	Url string `json:"url" name:"WebHook地址"`
+	Url         string `json:"url" require:"true" name:"WebHook地址"`
+	Method      string `json:"method" name:"请求方法" type:"select" options:"POST,PUT,GET" value:"POST" desc:"GET 请求只支持 form 格式，内容作为查询参数发送"`
+	Headers     string `json:"headers" name:"请求头" desc:"每行一个，格式为 Key: Value"`
+	Format      string `json:"format" name:"请求体格式" type:"select" options:"json,form,plain" value:"json"`
+	Body        string `json:"body" name:"请求体模板" desc:"可使用 {{.Title}} {{.Content}} {{.Time}}，变量会按请求体格式转义，为空使用默认模板"`
+	Secret      string `json:"secret" name:"签名密钥" desc:"设置后使用 HMAC-SHA256 对时间戳和请求体签名，放在 X-BestSub-Signature 请求头"`
+	Timeout     int    `json:"timeout" name:"超时时间" value:"10" desc:"单次请求的超时时间(s)"`
+	Retry       int    `json:"retry" name:"重试次数" value:"2" desc:"请求失败或服务端返回 5xx/429 时重试"`
+	Proxy       bool   `json:"proxy" name:"使用代理" value:"false" desc:"通过设置中的代理发送"`
+	contentType string
+	header      http.Header
+	tmpl        *template.Template
 }
 
 func (e *WebHook) Init() error {
+	u, err := url.Parse(e.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url: %s", e.Url)
+	}
+	e.Method = strings.ToUpper(strings.TrimSpace(e.Method))
+	if e.Method == "" {
+		e.Method = http.MethodPost
+	}
+	if e.Format == "" {
+		e.Format = webhookJSON
+	}
+	contentType, ok := webhookContentTypes[e.Format]
+	if !ok {
+		return fmt.Errorf("unsupported webhook format: %s", e.Format)
+	}
+	switch e.Method {
+	case http.MethodPost, http.MethodPut:
+	case http.MethodGet:
+		if e.Format != webhookForm {
+			return fmt.Errorf("webhook GET request only supports form format")
+		}
+	default:
+		return fmt.Errorf("unsupported webhook method: %s", e.Method)
+	}
+	e.contentType = contentType
+	e.header = make(http.Header)
+	for _, line := range strings.Split(e.Headers, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid webhook header: %s", line)
+		}
+		e.header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	body := e.Body
+	if strings.TrimSpace(body) == "" {
+		body = webhookBodies[e.Format]
+	}
+	if e.tmpl, err = template.New("webhook").Parse(body); err != nil {
+		return fmt.Errorf("parse webhook body template: %w", err)
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = 10
+	}
+	if e.Retry < 0 {
+		e.Retry = 0
+	}
 	return nil
 }
 
 func (e *WebHook) Send(title string, body *bytes.Buffer) error {
-	return nil
+	if body == nil {
+		return fmt.Errorf("webhook body is nil")
+	}
+	payload, err := e.render(title, body.String())
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for attempt := 0; attempt <= e.Retry; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		retry, err := e.do(payload)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return fmt.Errorf("send webhook failed: %w", lastErr)
+}
+
+// render 按请求体格式转义变量后执行模板
+func (e *WebHook) render(title, content string) ([]byte, error) {
+	escape := func(s string) string { return s }
+	switch e.Format {
+	case webhookJSON:
+		escape = func(s string) string {
+			b, _ := json.Marshal(s)
+			return string(b[1 : len(b)-1])
+		}
+	case webhookForm:
+		escape = url.QueryEscape
+	}
+	data := struct {
+		Title   string
+		Content string
+		Time    string
+	}{
+		Title:   escape(title),
+		Content: escape(content),
+		Time:    escape(time.Now().Format(time.DateTime)),
+	}
+	var buf bytes.Buffer
+	if err := e.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute webhook body template: %w", err)
+	}
+	if e.Format == webhookJSON && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook body is not valid json")
+	}
+	return buf.Bytes(), nil
+}
+
+// do 发送一次请求，返回是否值得重试
+func (e *WebHook) do(payload []byte) (bool, error) {
+	client := mihomo.Default(e.Proxy)
+	if client == nil {
+		return true, fmt.Errorf("failed to create http client")
+	}
+	defer client.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(e.Timeout)*time.Second)
+	defer cancel()
+
+	target := e.Url
+	var reqBody io.Reader = bytes.NewReader(payload)
+	if e.Method == http.MethodGet {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + string(payload)
+		reqBody = nil
+	}
+	req, err := http.NewRequestWithContext(ctx, e.Method, target, reqBody)
+	if err != nil {
+		return false, err
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", e.contentType)
+	}
+	req.Header.Set("User-Agent", "BestSub")
+	for key, values := range e.header {
+		req.Header[key] = values
+	}
+	if e.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(e.Secret))
+		mac.Write([]byte(timestamp))
+		mac.Write([]byte{'.'})
+		mac.Write(payload)
+		req.Header.Set("X-BestSub-Timestamp", timestamp)
+		req.Header.Set("X-BestSub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
 }
 
 func init() {
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"sync"
+
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// Handler 事件处理函数
+type Handler func(e notifyModel.Event)
+
+var (
+	handlersMu sync.RWMutex
+	handlers   []Handler
+)
+
+// Subscribe 订阅所有通知事件，系统通知之外的额外处理可以在这里注册
+func Subscribe(h Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers = append(handlers, h)
+}
+
+// Emit 异步分发事件，先发送系统通知，再交给订阅者处理，单个处理函数出错不影响其他处理函数
+func Emit(e notifyModel.Event) {
+	handlersMu.RLock()
+	hs := append([]Handler(nil), handlers...)
+	handlersMu.RUnlock()
+	go func() {
+		dispatch(e, func(e notifyModel.Event) { SendSystemNotify(e.Type(), e.Title(), e) })
+		for _, h := range hs {
+			dispatch(e, h)
+		}
+	}()
+}
+
+func dispatch(e notifyModel.Event, h Handler) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("notify event %s handler panic: %v", notifyModel.TypeMap[e.Type()], r)
+		}
+	}()
+	h(e)
+}
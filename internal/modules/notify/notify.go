@@ -2,7 +2,8 @@ package notify
 
 import (
 	"bytes"
-	"html/template"
+	htmltemplate "html/template"
+	"text/template"
 
 	"github.com/bestruirui/bestsub/internal/database/op"
 	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
@@ -19,36 +20,40 @@ func SendSystemNotify(operation uint16, title string, content any) error {
 	if operation&uint16(op.GetSettingInt(setting.NOTIFY_OPERATION)) == 0 {
 		return nil
 	}
+	return send(uint16(op.GetSettingInt(setting.NOTIFY_ID)), []string{notifyModel.TypeMap[operation]}, title, content)
+}
 
-	nt, err := op.GetNotifyTemplateByType(notifyModel.TypeMap[operation])
-	if err != nil {
-		log.Errorf("failed to get notify template: %v", operation)
-		return err
+// send 使用 templateTypes 中第一个存在的模板渲染内容并通过指定渠道发送
+func send(notifyID uint16, templateTypes []string, title string, content any) error {
+	var (
+		nt  string
+		err error
+	)
+	for _, t := range templateTypes {
+		if nt, err = op.GetNotifyTemplateByType(t); err == nil {
+			break
+		}
 	}
-
-	t, err := template.New("notify").Parse(nt)
 	if err != nil {
-		log.Errorf("failed to parse notify template: %v", err)
+		log.Errorf("failed to get notify template: %v", templateTypes)
 		return err
 	}
 
-	var buf bytes.Buffer
-	err = t.Execute(&buf, content)
+	notifyConfig, err := op.GetNotifyByID(notifyID)
 	if err != nil {
-		log.Errorf("failed to execute notify template: %v", err)
+		log.Errorf("failed to get notify config: %v", notifyID)
 		return err
 	}
 
-	sysNotifyID := op.GetSettingInt(setting.NOTIFY_ID)
-	notifyConfig, err := op.GetNotifyByID(uint16(sysNotifyID))
+	notify, err := Get(notifyConfig.Type, notifyConfig.Config)
 	if err != nil {
-		log.Errorf("failed to get notify config: %v", sysNotifyID)
+		log.Errorf("failed to get notify: %v", err)
 		return err
 	}
 
-	notify, err := Get(notifyConfig.Type, notifyConfig.Config)
-	if err != nil {
-		log.Errorf("failed to get notify: %v", err)
+	var buf bytes.Buffer
+	if err = render(&buf, nt, content, isHTML(notify)); err != nil {
+		log.Errorf("failed to render notify template: %v", err)
 		return err
 	}
 
@@ -67,6 +72,28 @@ func SendSystemNotify(operation uint16, title string, content any) error {
 	return nil
 }
 
+// isHTML 渠道是否以 HTML 发送内容
+func isHTML(notify notifyModel.Instance) bool {
+	h, ok := notify.(notifyModel.HTMLInstance)
+	return ok && h.HTML()
+}
+
+// render 渲染通知模板，只有 HTML 渠道对变量做 HTML 转义
+func render(buf *bytes.Buffer, text string, content any, html bool) error {
+	if html {
+		t, err := htmltemplate.New("notify").Parse(text)
+		if err != nil {
+			return err
+		}
+		return t.Execute(buf, content)
+	}
+	t, err := template.New("notify").Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(buf, content)
+}
+
 func Get(m string, c string) (notifyModel.Instance, error) {
 	return register.Get[notifyModel.Instance]("notify", m, c)
 }
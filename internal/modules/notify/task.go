@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"math"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+)
+
+// defaultTaskChange 未设置变化阈值时使用的百分比
+const defaultTaskChange = 20
+
+// SendTaskNotify 按任务的通知设置发送运行结果，channel 为0时使用系统通知渠道
+func SendTaskNotify(channel int, mode string, change int, result *notifyModel.TaskResult) error {
+	if !shouldNotify(mode, change, result) {
+		return nil
+	}
+	notifyID := uint16(channel)
+	if notifyID == 0 {
+		notifyID = uint16(op.GetSettingInt(setting.NOTIFY_ID))
+	}
+	return send(notifyID, result.Templates(), result.Title(), result)
+}
+
+func shouldNotify(mode string, change int, result *notifyModel.TaskResult) bool {
+	switch mode {
+	case notifyModel.TaskNotifyFailure:
+		return result.Failed
+	case notifyModel.TaskNotifyChange:
+		if change == 0 {
+			change = defaultTaskChange
+		}
+		return result.Failed || changed(result, float64(change))
+	default:
+		return true
+	}
+}
+
+// changed 统计中任意数值相对上一次的变化达到 percent 即视为变化，没有数值统计时比较消息
+func changed(result *notifyModel.TaskResult, percent float64) bool {
+	if result.Prev == nil {
+		return result.PrevMsg != result.Msg
+	}
+	numeric := false
+	for k, v := range result.Extra {
+		cur, ok := toFloat(v)
+		if !ok {
+			continue
+		}
+		numeric = true
+		prev, ok := toFloat(result.Prev[k])
+		if !ok {
+			return true
+		}
+		if prev == 0 {
+			if cur != 0 {
+				return true
+			}
+			continue
+		}
+		if math.Abs(cur-prev)/math.Abs(prev)*100 >= percent {
+			return true
+		}
+	}
+	if !numeric {
+		return result.PrevMsg != result.Msg
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
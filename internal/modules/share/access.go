@@ -0,0 +1,136 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+)
+
+var (
+	ErrAccessDenied = errors.New("access denied")
+	ErrTooManyIP    = errors.New("too many client ip")
+	ErrTooManyUA    = errors.New("too many client user agent")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+)
+
+// clientGuard 单个分享链接的客户端记录
+type clientGuard struct {
+	mu       sync.Mutex
+	restored bool
+	ips      map[string]struct{}
+	agents   map[string]struct{}
+	hits     map[string][]time.Time
+	swept    time.Time
+}
+
+var guards generic.MapOf[uint16, *clientGuard]
+
+// CheckAccess 检查访问控制，客户端集合首次使用时从访问记录中恢复
+func CheckAccess(ctx context.Context, data *share.Data, ip string, userAgent string) error {
+	access := data.AccessConfig()
+	prefixes, err := access.Prefixes()
+	if err != nil {
+		return err
+	}
+	if len(prefixes) > 0 {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return ErrAccessDenied
+		}
+		addr = addr.Unmap()
+		if !slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+			return ErrAccessDenied
+		}
+	}
+	if access.MaxClientIP == 0 && access.MaxClientUA == 0 && access.RateLimit == 0 {
+		return nil
+	}
+
+	g, _ := guards.LoadOrStore(data.ID, &clientGuard{
+		ips:    make(map[string]struct{}),
+		agents: make(map[string]struct{}),
+		hits:   make(map[string][]time.Time),
+	})
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.restored {
+		g.restore(ctx, data)
+		g.restored = true
+	}
+
+	now := time.Now()
+	g.sweep(now)
+	_, knownIP := g.ips[ip]
+	if !knownIP && access.MaxClientIP > 0 && len(g.ips) >= int(access.MaxClientIP) {
+		return ErrTooManyIP
+	}
+	_, knownUA := g.agents[userAgent]
+	if !knownUA && access.MaxClientUA > 0 && len(g.agents) >= int(access.MaxClientUA) {
+		return ErrTooManyUA
+	}
+	if access.RateLimit > 0 {
+		hits := expire(g.hits[ip], now)
+		if len(hits) >= int(access.RateLimit) {
+			g.hits[ip] = hits
+			return ErrRateLimited
+		}
+		g.hits[ip] = append(hits, now)
+	}
+	// 未限制数量时无需记录，避免集合无限增长
+	if access.MaxClientIP > 0 {
+		g.ips[ip] = struct{}{}
+	}
+	if access.MaxClientUA > 0 {
+		g.agents[userAgent] = struct{}{}
+	}
+	return nil
+}
+
+// expire 移除一分钟窗口之外的访问时间
+func expire(hits []time.Time, now time.Time) []time.Time {
+	window := now.Add(-time.Minute)
+	return slices.DeleteFunc(hits, func(t time.Time) bool { return t.Before(window) })
+}
+
+// sweep 每分钟清理一次窗口内已无访问的IP
+func (g *clientGuard) sweep(now time.Time) {
+	if now.Sub(g.swept) < time.Minute {
+		return
+	}
+	g.swept = now
+	for ip, hits := range g.hits {
+		if hits = expire(hits, now); len(hits) == 0 {
+			delete(g.hits, ip)
+		} else {
+			g.hits[ip] = hits
+		}
+	}
+}
+
+// restore 从最近的访问记录恢复客户端集合，仅统计当前token生效后的访问
+func (g *clientGuard) restore(ctx context.Context, data *share.Data) {
+	logs, err := op.GetShareAccessLog(ctx, data.ID, op.GetSettingInt(setting.SHARE_ACCESS_LOG_LIMIT))
+	if err != nil {
+		return
+	}
+	for _, l := range logs {
+		if uint64(l.AccessedAt) < data.TokenRotatedAt {
+			continue
+		}
+		g.ips[l.IP] = struct{}{}
+		g.agents[l.UserAgent] = struct{}{}
+	}
+}
+
+// ResetAccess 清除分享链接的客户端记录，在更新配置或更换token后调用
+func ResetAccess(id uint16) {
+	guards.Delete(id)
+}
@@ -0,0 +1,130 @@
+package share
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/cespare/xxhash/v2"
+)
+
+type cacheKey struct {
+	shareID uint16
+	kind    string
+	variant string
+}
+
+type cacheEntry struct {
+	result     Result
+	etag       string
+	generation uint64
+	config     uint64
+	expires    time.Time
+}
+
+// maxCacheEntries 单个分享最多缓存的输出数量
+const maxCacheEntries = 16
+
+var renderCache generic.MapOf[cacheKey, cacheEntry]
+
+// Cached 获取缓存的分享内容，节点池或分享配置变化、超过 SHARE_CACHE_TTL 时重新生成
+// variant 区分同一分享的不同输出，例如格式与查询参数
+func Cached(data *share.Data, kind string, variant string, render func() Result) (Result, string) {
+	ttl := time.Duration(op.GetSettingInt(setting.SHARE_CACHE_TTL)) * time.Second
+	if ttl <= 0 {
+		result := render()
+		return result, etagOf(result.Data)
+	}
+	key := cacheKey{shareID: data.ID, kind: kind, variant: variant}
+	generation := node.Generation()
+	config := configVersion(data)
+	if entry, ok := renderCache.Load(key); ok &&
+		entry.generation == generation && entry.config == config && time.Now().Before(entry.expires) {
+		return entry.result, entry.etag
+	}
+	result := render()
+	etag := etagOf(result.Data)
+	if len(result.Data) > 0 {
+		sweepCache(data.ID)
+		renderCache.Store(key, cacheEntry{
+			result:     result,
+			etag:       etag,
+			generation: generation,
+			config:     config,
+			expires:    time.Now().Add(ttl),
+		})
+	}
+	return result, etag
+}
+
+// InvalidateCache 清除分享的全部缓存
+func InvalidateCache(id uint16) {
+	renderCache.Range(func(key cacheKey, _ cacheEntry) bool {
+		if key.shareID == id {
+			renderCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// sweepCache 清除过期的缓存，分享的缓存数量达到上限时清除最早过期的一项
+func sweepCache(id uint16) {
+	now := time.Now()
+	var count int
+	var oldest cacheKey
+	var oldestExpires time.Time
+	renderCache.Range(func(key cacheKey, entry cacheEntry) bool {
+		if now.After(entry.expires) {
+			renderCache.Delete(key)
+			return true
+		}
+		if key.shareID == id {
+			count++
+			if oldestExpires.IsZero() || entry.expires.Before(oldestExpires) {
+				oldest, oldestExpires = key, entry.expires
+			}
+		}
+		return true
+	})
+	if count >= maxCacheEntries {
+		renderCache.Delete(oldest)
+	}
+}
+
+// configVersion 分享配置及其引用模板的版本
+func configVersion(data *share.Data) uint64 {
+	h := xxhash.New()
+	h.WriteString(data.Gen)
+	for _, t := range templateVersions(data.Gen) {
+		binary.Write(h, binary.LittleEndian, t)
+	}
+	return h.Sum64()
+}
+
+func templateVersions(gen string) []uint32 {
+	var genConfig share.GenConfig
+	if err := json.Unmarshal([]byte(gen), &genConfig); err != nil {
+		return nil
+	}
+	var versions []uint32
+	for _, id := range []uint16{genConfig.Template.Clash, genConfig.Template.SingBox} {
+		if id == 0 {
+			continue
+		}
+		if t, err := op.GetTemplateByID(context.Background(), id); err == nil {
+			versions = append(versions, t.Version)
+		}
+	}
+	return versions
+}
+
+func etagOf(data []byte) string {
+	return fmt.Sprintf(`"%016x"`, xxhash.Sum64(data))
+}
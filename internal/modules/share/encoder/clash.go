@@ -0,0 +1,75 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type Clash struct{}
+
+func (e *Clash) Name() string {
+	return "clash"
+}
+
+func (e *Clash) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *Clash) UserAgent() []string {
+	return []string{"clash", "mihomo", "stash"}
+}
+
+func (e *Clash) Encode(proxies []Proxy) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("proxies:\n")
+	for _, p := range proxies {
+		buf.WriteString(" - ")
+		buf.Write(p.Raw)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *Clash) EncodeGroups(proxies []Proxy, groups []Group) ([]byte, error) {
+	data, err := e.Encode(proxies)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(data)
+	buf.WriteString("proxy-groups:\n")
+	for _, g := range groups {
+		if len(g.Proxies) == 0 {
+			continue
+		}
+		line, err := ClashGroup(g)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(" - ")
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// ClashGroup 生成 flow 格式的 proxy-groups 配置
+func ClashGroup(g Group) (string, error) {
+	name, err := json.Marshal(g.Name)
+	if err != nil {
+		return "", err
+	}
+	proxies, err := json.Marshal(g.Proxies)
+	if err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("{name: %s, type: %s", name, g.Type)
+	if g.Type != "select" {
+		line += fmt.Sprintf(", url: %s, interval: 300", GroupTestUrl)
+	}
+	return line + fmt.Sprintf(", proxies: %s}", proxies), nil
+}
+
+func init() {
+	register(&Clash{})
+}
@@ -0,0 +1,99 @@
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func str(m map[string]any, key string) string {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return ""
+	}
+	switch t := v.(type) {
+	case string:
+		return t
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func num(m map[string]any, key string) int {
+	switch t := m[key].(type) {
+	case int:
+		return t
+	case int64:
+		return int(t)
+	case uint64:
+		return int(t)
+	case float64:
+		return int(t)
+	case string:
+		i, _ := strconv.Atoi(t)
+		return i
+	}
+	return 0
+}
+
+func boolean(m map[string]any, key string) bool {
+	switch t := m[key].(type) {
+	case bool:
+		return t
+	case string:
+		return t == "true"
+	}
+	return false
+}
+
+func sub(m map[string]any, key string) map[string]any {
+	if v, ok := m[key].(map[string]any); ok {
+		return v
+	}
+	return map[string]any{}
+}
+
+func strs(m map[string]any, key string) []string {
+	switch t := m[key].(type) {
+	case []any:
+		result := make([]string, 0, len(t))
+		for _, v := range t {
+			result = append(result, fmt.Sprint(v))
+		}
+		return result
+	case string:
+		if t == "" {
+			return nil
+		}
+		return strings.Split(t, ",")
+	}
+	return nil
+}
+
+// sni 兼容 servername 与 sni 两种写法
+func sni(m map[string]any) string {
+	if s := str(m, "servername"); s != "" {
+		return s
+	}
+	return str(m, "sni")
+}
+
+// wsHost 获取 ws-opts.headers.Host
+func wsHost(m map[string]any) string {
+	headers := sub(sub(m, "ws-opts"), "headers")
+	if h := str(headers, "Host"); h != "" {
+		return h
+	}
+	return str(headers, "host")
+}
+
+func wsPath(m map[string]any) string {
+	return str(sub(m, "ws-opts"), "path")
+}
+
+func grpcService(m map[string]any) string {
+	return str(sub(m, "grpc-opts"), "grpc-service-name")
+}
+
+// lineName 清理逗号与等号，避免破坏按行分隔的配置格式
+var lineName = strings.NewReplacer(",", " ", "=", "-", "\n", " ")
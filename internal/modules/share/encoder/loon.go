@@ -0,0 +1,157 @@
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type Loon struct{}
+
+func (e *Loon) Name() string {
+	return "loon"
+}
+
+func (e *Loon) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *Loon) UserAgent() []string {
+	return []string{"loon"}
+}
+
+func (e *Loon) Encode(proxies []Proxy) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, p := range proxies {
+		line := loonLine(p.Map)
+		if line == "" {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func loonLine(m map[string]any) string {
+	var fields []string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+value)
+		}
+	}
+	transport := func() bool {
+		switch str(m, "network") {
+		case "", "tcp":
+			add("transport", "tcp")
+		case "ws":
+			add("transport", "ws")
+			add("path", wsPath(m))
+			add("host", wsHost(m))
+		case "http":
+			add("transport", "http")
+		default:
+			return false
+		}
+		return true
+	}
+	// params 为协议关键字之后按位置排列的参数
+	var typ string
+	var params []string
+	quoted := func(value string) bool {
+		if strings.ContainsAny(value, "\"\r\n") {
+			return false
+		}
+		params = append(params, `"`+value+`"`)
+		return true
+	}
+	switch str(m, "type") {
+	case "ss":
+		typ = "Shadowsocks"
+		params = append(params, str(m, "cipher"))
+		if !quoted(str(m, "password")) {
+			return ""
+		}
+		if str(m, "plugin") == "obfs" {
+			opts := sub(m, "plugin-opts")
+			add("obfs-name", str(opts, "mode"))
+			add("obfs-host", str(opts, "host"))
+		} else if str(m, "plugin") != "" {
+			return ""
+		}
+	case "vmess":
+		cipher := str(m, "cipher")
+		if cipher == "" {
+			cipher = "auto"
+		}
+		typ = "vmess"
+		params = append(params, cipher)
+		if !quoted(str(m, "uuid")) || !transport() {
+			return ""
+		}
+		if boolean(m, "tls") {
+			add("over-tls", "true")
+			add("tls-name", sni(m))
+		}
+		add("alterId", fmt.Sprint(num(m, "alterId")))
+	case "vless":
+		typ = "VLESS"
+		if !quoted(str(m, "uuid")) || !transport() {
+			return ""
+		}
+		add("flow", str(m, "flow"))
+		if reality := sub(m, "reality-opts"); len(reality) > 0 {
+			add("public-key", str(reality, "public-key"))
+			add("short-id", str(reality, "short-id"))
+			add("sni", sni(m))
+		} else if boolean(m, "tls") {
+			add("over-tls", "true")
+			add("tls-name", sni(m))
+		}
+	case "trojan":
+		typ = "trojan"
+		if !quoted(str(m, "password")) || !transport() {
+			return ""
+		}
+		add("tls-name", sni(m))
+	case "hysteria2":
+		typ = "Hysteria2"
+		if !quoted(str(m, "password")) {
+			return ""
+		}
+		add("sni", sni(m))
+	case "http", "socks5":
+		typ = "http"
+		if str(m, "type") == "socks5" {
+			typ = "socks5"
+		} else if boolean(m, "tls") {
+			typ = "https"
+		}
+		if username := str(m, "username"); username != "" {
+			params = append(params, username)
+			if !quoted(str(m, "password")) {
+				return ""
+			}
+		}
+	default:
+		return ""
+	}
+	if boolean(m, "skip-cert-verify") {
+		add("skip-cert-verify", "true")
+	}
+	if boolean(m, "udp") {
+		add("udp", "true")
+	}
+	head := fmt.Sprintf("%s = %s,%s,%d", lineName.Replace(str(m, "name")), typ, str(m, "server"), num(m, "port"))
+	if len(params) > 0 {
+		head += "," + strings.Join(params, ",")
+	}
+	if len(fields) == 0 {
+		return head
+	}
+	return head + "," + strings.Join(fields, ",")
+}
+
+func init() {
+	register(&Loon{})
+}
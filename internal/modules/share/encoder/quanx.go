@@ -0,0 +1,141 @@
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type QuanX struct{}
+
+func (e *QuanX) Name() string {
+	return "quanx"
+}
+
+func (e *QuanX) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *QuanX) UserAgent() []string {
+	return []string{"quantumult"}
+}
+
+func (e *QuanX) Encode(proxies []Proxy) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, p := range proxies {
+		line := quanXLine(p.Map)
+		if line == "" {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func quanXLine(m map[string]any) string {
+	var fields []string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+value)
+		}
+	}
+	server := fmt.Sprintf("%s:%d", str(m, "server"), num(m, "port"))
+	// transport 处理 ws/tls 的 obfs 写法，返回 false 表示不支持
+	transport := func(tls bool) bool {
+		switch str(m, "network") {
+		case "", "tcp":
+			if tls {
+				add("obfs", "over-tls")
+				add("obfs-host", sni(m))
+			}
+		case "ws":
+			if tls {
+				add("obfs", "wss")
+			} else {
+				add("obfs", "ws")
+			}
+			add("obfs-host", wsHost(m))
+			add("obfs-uri", wsPath(m))
+		default:
+			return false
+		}
+		return true
+	}
+	switch str(m, "type") {
+	case "ss":
+		fields = append(fields, "shadowsocks="+server)
+		add("method", str(m, "cipher"))
+		add("password", str(m, "password"))
+		if str(m, "plugin") == "obfs" {
+			opts := sub(m, "plugin-opts")
+			add("obfs", str(opts, "mode"))
+			add("obfs-host", str(opts, "host"))
+		} else if str(m, "plugin") != "" {
+			return ""
+		}
+		if boolean(m, "udp") {
+			add("udp-relay", "true")
+		}
+	case "vmess":
+		fields = append(fields, "vmess="+server)
+		method := str(m, "cipher")
+		if method == "" || method == "auto" {
+			method = "chacha20-ietf-poly1305"
+		}
+		add("method", method)
+		add("password", str(m, "uuid"))
+		if !transport(boolean(m, "tls")) {
+			return ""
+		}
+		if num(m, "alterId") == 0 {
+			add("aead", "true")
+		}
+	case "vless":
+		if str(m, "flow") != "" || len(sub(m, "reality-opts")) > 0 {
+			return ""
+		}
+		fields = append(fields, "vless="+server)
+		add("method", "none")
+		add("password", str(m, "uuid"))
+		if !transport(boolean(m, "tls")) {
+			return ""
+		}
+	case "trojan":
+		fields = append(fields, "trojan="+server)
+		add("password", str(m, "password"))
+		if str(m, "network") == "ws" {
+			if !transport(true) {
+				return ""
+			}
+		} else {
+			add("over-tls", "true")
+			add("tls-host", sni(m))
+		}
+	case "http":
+		fields = append(fields, "http="+server)
+		add("username", str(m, "username"))
+		add("password", str(m, "password"))
+		if boolean(m, "tls") {
+			add("over-tls", "true")
+		}
+	case "socks5":
+		fields = append(fields, "socks5="+server)
+		add("username", str(m, "username"))
+		add("password", str(m, "password"))
+		if boolean(m, "tls") {
+			add("over-tls", "true")
+		}
+	default:
+		return ""
+	}
+	if boolean(m, "skip-cert-verify") {
+		add("tls-verification", "false")
+	}
+	add("tag", lineName.Replace(str(m, "name")))
+	return strings.Join(fields, ", ")
+}
+
+func init() {
+	register(&QuanX{})
+}
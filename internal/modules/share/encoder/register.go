@@ -0,0 +1,76 @@
+package encoder
+
+import (
+	"strings"
+)
+
+// Proxy 经过筛选与重命名后的节点
+type Proxy struct {
+	Raw []byte         // mihomo flow 格式的原始节点
+	Map map[string]any // 解析后的节点
+}
+
+type Encoder interface {
+	// Name 对应分享链接的 target 参数
+	Name() string
+	// ContentType 响应的 Content-Type
+	ContentType() string
+	// UserAgent 用于自动识别客户端的UA关键字(小写)
+	UserAgent() []string
+	Encode(proxies []Proxy) ([]byte, error)
+}
+
+// Group 分享分组对应的代理组
+type Group struct {
+	Name    string
+	Type    string // select/url-test/fallback/load-balance
+	Proxies []string
+}
+
+// GroupEncoder 支持同时输出代理组的编码器
+type GroupEncoder interface {
+	// EncodeGroups 输出节点与代理组，没有节点的代理组会被忽略
+	EncodeGroups(proxies []Proxy, groups []Group) ([]byte, error)
+}
+
+// GroupTestUrl 自动测速类代理组使用的测试地址
+const GroupTestUrl = "http://www.gstatic.com/generate_204"
+
+var encoders = make([]Encoder, 0)
+
+func register(encoder Encoder) {
+	encoders = append(encoders, encoder)
+}
+
+// Get 根据 target 获取编码器
+func Get(target string) (Encoder, bool) {
+	target = strings.ToLower(target)
+	for _, e := range encoders {
+		if e.Name() == target {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Detect 根据 User-Agent 识别客户端对应的编码器
+func Detect(userAgent string) (Encoder, bool) {
+	userAgent = strings.ToLower(userAgent)
+	for _, e := range encoders {
+		for _, keyword := range e.UserAgent() {
+			if strings.Contains(userAgent, keyword) {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Targets 获取所有支持的 target
+func Targets() []string {
+	targets := make([]string, 0, len(encoders))
+	for _, e := range encoders {
+		targets = append(targets, e.Name())
+	}
+	return targets
+}
@@ -0,0 +1,205 @@
+package encoder
+
+import (
+	"encoding/json"
+)
+
+type SingBox struct{}
+
+func (e *SingBox) Name() string {
+	return "singbox"
+}
+
+func (e *SingBox) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (e *SingBox) UserAgent() []string {
+	return []string{"sing-box", "singbox", "sfa", "sfi", "sfm"}
+}
+
+func (e *SingBox) Encode(proxies []Proxy) ([]byte, error) {
+	outbounds := make([]map[string]any, 0, len(proxies))
+	for _, p := range proxies {
+		if outbound := SingBoxOutbound(p.Map); outbound != nil {
+			outbounds = append(outbounds, outbound)
+		}
+	}
+	return json.MarshalIndent(map[string]any{"outbounds": outbounds}, "", "  ")
+}
+
+func (e *SingBox) EncodeGroups(proxies []Proxy, groups []Group) ([]byte, error) {
+	outbounds := make([]map[string]any, 0, len(proxies)+len(groups))
+	supported := make(map[string]struct{}, len(proxies))
+	for _, p := range proxies {
+		if outbound := SingBoxOutbound(p.Map); outbound != nil {
+			outbounds = append(outbounds, outbound)
+			supported[str(p.Map, "name")] = struct{}{}
+		}
+	}
+	for _, g := range groups {
+		tags := make([]string, 0, len(g.Proxies))
+		for _, name := range g.Proxies {
+			if _, ok := supported[name]; ok {
+				tags = append(tags, name)
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		g.Proxies = tags
+		outbounds = append(outbounds, SingBoxGroup(g))
+	}
+	return json.MarshalIndent(map[string]any{"outbounds": outbounds}, "", "  ")
+}
+
+// SingBoxGroup 将代理组转换为 sing-box outbound，fallback 与 load-balance 按 urltest 处理
+func SingBoxGroup(g Group) map[string]any {
+	switch g.Type {
+	case "select", "selector", "":
+		return map[string]any{"type": "selector", "tag": g.Name, "outbounds": g.Proxies}
+	}
+	return map[string]any{"type": "urltest", "tag": g.Name, "outbounds": g.Proxies, "url": GroupTestUrl}
+}
+
+// SingBoxOutbound 将 mihomo 节点转换为 sing-box outbound，不支持的协议返回 nil
+func SingBoxOutbound(m map[string]any) map[string]any {
+	out := map[string]any{
+		"tag":         str(m, "name"),
+		"server":      str(m, "server"),
+		"server_port": num(m, "port"),
+	}
+	switch str(m, "type") {
+	case "ss":
+		out["type"] = "shadowsocks"
+		out["method"] = str(m, "cipher")
+		out["password"] = str(m, "password")
+		if plugin := str(m, "plugin"); plugin != "" {
+			opts := sub(m, "plugin-opts")
+			switch plugin {
+			case "obfs":
+				out["plugin"] = "obfs-local"
+				out["plugin_opts"] = "obfs=" + str(opts, "mode") + ";obfs-host=" + str(opts, "host")
+			case "v2ray-plugin":
+				out["plugin"] = "v2ray-plugin"
+				pluginOpts := "mode=" + str(opts, "mode") + ";host=" + str(opts, "host") + ";path=" + str(opts, "path")
+				if boolean(opts, "tls") {
+					pluginOpts += ";tls"
+				}
+				out["plugin_opts"] = pluginOpts
+			default:
+				return nil
+			}
+		}
+	case "vmess":
+		out["type"] = "vmess"
+		out["uuid"] = str(m, "uuid")
+		out["alter_id"] = num(m, "alterId")
+		security := str(m, "cipher")
+		if security == "" {
+			security = "auto"
+		}
+		out["security"] = security
+	case "vless":
+		out["type"] = "vless"
+		out["uuid"] = str(m, "uuid")
+		if flow := str(m, "flow"); flow != "" {
+			out["flow"] = flow
+		}
+	case "trojan":
+		out["type"] = "trojan"
+		out["password"] = str(m, "password")
+	case "hysteria2":
+		out["type"] = "hysteria2"
+		out["password"] = str(m, "password")
+		if obfs := str(m, "obfs"); obfs != "" {
+			out["obfs"] = map[string]any{"type": obfs, "password": str(m, "obfs-password")}
+		}
+	case "tuic":
+		out["type"] = "tuic"
+		out["uuid"] = str(m, "uuid")
+		out["password"] = str(m, "password")
+		if cc := str(m, "congestion-controller"); cc != "" {
+			out["congestion_control"] = cc
+		}
+	case "socks5":
+		out["type"] = "socks"
+		out["version"] = "5"
+		if username := str(m, "username"); username != "" {
+			out["username"] = username
+			out["password"] = str(m, "password")
+		}
+	case "http":
+		out["type"] = "http"
+		if username := str(m, "username"); username != "" {
+			out["username"] = username
+			out["password"] = str(m, "password")
+		}
+	default:
+		return nil
+	}
+
+	if tls := singBoxTLS(m); tls != nil {
+		out["tls"] = tls
+	}
+	if transport := singBoxTransport(m); transport != nil {
+		out["transport"] = transport
+	}
+	return out
+}
+
+func singBoxTLS(m map[string]any) map[string]any {
+	t := str(m, "type")
+	if !boolean(m, "tls") && t != "trojan" && t != "hysteria2" && t != "tuic" {
+		return nil
+	}
+	tls := map[string]any{"enabled": true}
+	if s := sni(m); s != "" {
+		tls["server_name"] = s
+	}
+	if boolean(m, "skip-cert-verify") {
+		tls["insecure"] = true
+	}
+	if alpn := strs(m, "alpn"); len(alpn) > 0 {
+		tls["alpn"] = alpn
+	}
+	if fp := str(m, "client-fingerprint"); fp != "" {
+		tls["utls"] = map[string]any{"enabled": true, "fingerprint": fp}
+	}
+	if reality := sub(m, "reality-opts"); len(reality) > 0 {
+		tls["reality"] = map[string]any{
+			"enabled":    true,
+			"public_key": str(reality, "public-key"),
+			"short_id":   str(reality, "short-id"),
+		}
+	}
+	return tls
+}
+
+func singBoxTransport(m map[string]any) map[string]any {
+	switch str(m, "network") {
+	case "ws":
+		transport := map[string]any{"type": "ws", "path": wsPath(m)}
+		if host := wsHost(m); host != "" {
+			transport["headers"] = map[string]any{"Host": host}
+		}
+		return transport
+	case "grpc":
+		return map[string]any{"type": "grpc", "service_name": grpcService(m)}
+	case "h2":
+		opts := sub(m, "h2-opts")
+		return map[string]any{"type": "http", "host": strs(opts, "host"), "path": str(opts, "path")}
+	case "http":
+		opts := sub(m, "http-opts")
+		transport := map[string]any{"type": "http", "method": str(opts, "method")}
+		if paths := strs(opts, "path"); len(paths) > 0 {
+			transport["path"] = paths[0]
+		}
+		return transport
+	}
+	return nil
+}
+
+func init() {
+	register(&SingBox{})
+}
@@ -0,0 +1,130 @@
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type Surge struct{}
+
+func (e *Surge) Name() string {
+	return "surge"
+}
+
+func (e *Surge) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *Surge) UserAgent() []string {
+	return []string{"surge"}
+}
+
+func (e *Surge) Encode(proxies []Proxy) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("[Proxy]\n")
+	for _, p := range proxies {
+		line := surgeLine(p.Map)
+		if line == "" {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func surgeLine(m map[string]any) string {
+	var fields []string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+value)
+		}
+	}
+	typ, auth := str(m, "type"), ""
+	switch typ {
+	case "ss":
+		add("encrypt-method", str(m, "cipher"))
+		add("password", str(m, "password"))
+		if str(m, "plugin") == "obfs" {
+			opts := sub(m, "plugin-opts")
+			add("obfs", str(opts, "mode"))
+			add("obfs-host", str(opts, "host"))
+		} else if str(m, "plugin") != "" {
+			return ""
+		}
+		if boolean(m, "udp") {
+			add("udp-relay", "true")
+		}
+	case "vmess":
+		add("username", str(m, "uuid"))
+		if num(m, "alterId") == 0 {
+			add("vmess-aead", "true")
+		}
+		switch str(m, "network") {
+		case "", "tcp":
+		case "ws":
+			add("ws", "true")
+			add("ws-path", wsPath(m))
+			if host := wsHost(m); host != "" {
+				add("ws-headers", "Host:"+host)
+			}
+		default:
+			return ""
+		}
+		if boolean(m, "tls") {
+			add("tls", "true")
+			add("sni", sni(m))
+		}
+	case "trojan":
+		add("password", str(m, "password"))
+		switch str(m, "network") {
+		case "", "tcp":
+		case "ws":
+			add("ws", "true")
+			add("ws-path", wsPath(m))
+			if host := wsHost(m); host != "" {
+				add("ws-headers", "Host:"+host)
+			}
+		default:
+			return ""
+		}
+		add("sni", sni(m))
+	case "hysteria2":
+		add("password", str(m, "password"))
+		add("sni", sni(m))
+	case "tuic":
+		add("token", str(m, "token"))
+		if v := strs(m, "alpn"); len(v) > 0 {
+			add("alpn", v[0])
+		}
+		add("sni", sni(m))
+		if str(m, "token") == "" {
+			return ""
+		}
+	case "socks5", "http":
+		if boolean(m, "tls") {
+			typ += "-tls"
+			if typ == "http-tls" {
+				typ = "https"
+			}
+		}
+		if username := str(m, "username"); username != "" {
+			auth = ", " + username + ", " + str(m, "password")
+		}
+	default:
+		return ""
+	}
+	if boolean(m, "skip-cert-verify") {
+		add("skip-cert-verify", "true")
+	}
+	head := fmt.Sprintf("%s = %s, %s, %d%s", lineName.Replace(str(m, "name")), typ, str(m, "server"), num(m, "port"), auth)
+	if len(fields) == 0 {
+		return head
+	}
+	return head + ", " + strings.Join(fields, ", ")
+}
+
+func init() {
+	register(&Surge{})
+}
@@ -0,0 +1,199 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type V2Ray struct{}
+
+func (e *V2Ray) Name() string {
+	return "v2ray"
+}
+
+func (e *V2Ray) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *V2Ray) UserAgent() []string {
+	return []string{"v2ray", "shadowrocket", "nekobox", "nekoray"}
+}
+
+func (e *V2Ray) Encode(proxies []Proxy) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, p := range proxies {
+		link := ShareLink(p.Map)
+		if link == "" {
+			continue
+		}
+		buf.WriteString(link)
+		buf.WriteByte('\n')
+	}
+	out := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(out, buf.Bytes())
+	return out, nil
+}
+
+// ShareLink 将 mihomo 节点转换为通用分享链接，不支持的协议返回空字符串
+func ShareLink(m map[string]any) string {
+	name := url.PathEscape(str(m, "name"))
+	host := net.JoinHostPort(str(m, "server"), strconv.Itoa(num(m, "port")))
+	switch str(m, "type") {
+	case "ss":
+		userInfo := base64.RawURLEncoding.EncodeToString([]byte(str(m, "cipher") + ":" + str(m, "password")))
+		link := fmt.Sprintf("ss://%s@%s", userInfo, host)
+		if plugin := str(m, "plugin"); plugin != "" {
+			opts := sub(m, "plugin-opts")
+			var pluginStr string
+			switch plugin {
+			case "obfs":
+				pluginStr = "obfs-local;obfs=" + str(opts, "mode") + ";obfs-host=" + str(opts, "host")
+			case "v2ray-plugin":
+				pluginStr = "v2ray-plugin;mode=" + str(opts, "mode") + ";host=" + str(opts, "host") + ";path=" + str(opts, "path")
+				if boolean(opts, "tls") {
+					pluginStr += ";tls"
+				}
+			default:
+				return ""
+			}
+			link += "/?plugin=" + url.QueryEscape(pluginStr)
+		}
+		return link + "#" + name
+	case "ssr":
+		params := url.Values{}
+		params.Set("remarks", base64.RawURLEncoding.EncodeToString([]byte(str(m, "name"))))
+		params.Set("obfsparam", base64.RawURLEncoding.EncodeToString([]byte(str(m, "obfs-param"))))
+		params.Set("protoparam", base64.RawURLEncoding.EncodeToString([]byte(str(m, "protocol-param"))))
+		body := fmt.Sprintf("%s:%d:%s:%s:%s:%s/?%s",
+			str(m, "server"), num(m, "port"), str(m, "protocol"), str(m, "cipher"), str(m, "obfs"),
+			base64.RawURLEncoding.EncodeToString([]byte(str(m, "password"))), params.Encode())
+		return "ssr://" + base64.RawURLEncoding.EncodeToString([]byte(body))
+	case "vmess":
+		network := str(m, "network")
+		if network == "" {
+			network = "tcp"
+		}
+		v := map[string]any{
+			"v":    "2",
+			"ps":   str(m, "name"),
+			"add":  str(m, "server"),
+			"port": strconv.Itoa(num(m, "port")),
+			"id":   str(m, "uuid"),
+			"aid":  strconv.Itoa(num(m, "alterId")),
+			"scy":  str(m, "cipher"),
+			"net":  network,
+			"type": "none",
+			"sni":  sni(m),
+		}
+		switch network {
+		case "ws":
+			v["host"] = wsHost(m)
+			v["path"] = wsPath(m)
+		case "grpc":
+			v["path"] = grpcService(m)
+		case "h2":
+			opts := sub(m, "h2-opts")
+			v["host"] = strings.Join(strs(opts, "host"), ",")
+			v["path"] = str(opts, "path")
+		}
+		if boolean(m, "tls") {
+			v["tls"] = "tls"
+		}
+		data, _ := json.Marshal(v)
+		return "vmess://" + base64.StdEncoding.EncodeToString(data)
+	case "vless":
+		query := linkTransport(m)
+		query.Set("encryption", "none")
+		if flow := str(m, "flow"); flow != "" {
+			query.Set("flow", flow)
+		}
+		if reality := sub(m, "reality-opts"); len(reality) > 0 {
+			query.Set("security", "reality")
+			query.Set("pbk", str(reality, "public-key"))
+			query.Set("sid", str(reality, "short-id"))
+		} else if boolean(m, "tls") {
+			query.Set("security", "tls")
+		}
+		return fmt.Sprintf("vless://%s@%s?%s#%s", url.PathEscape(str(m, "uuid")), host, query.Encode(), name)
+	case "trojan":
+		query := linkTransport(m)
+		query.Set("security", "tls")
+		return fmt.Sprintf("trojan://%s@%s?%s#%s", url.PathEscape(str(m, "password")), host, query.Encode(), name)
+	case "hysteria2":
+		query := url.Values{}
+		if s := sni(m); s != "" {
+			query.Set("sni", s)
+		}
+		if obfs := str(m, "obfs"); obfs != "" {
+			query.Set("obfs", obfs)
+			query.Set("obfs-password", str(m, "obfs-password"))
+		}
+		if boolean(m, "skip-cert-verify") {
+			query.Set("insecure", "1")
+		}
+		return fmt.Sprintf("hysteria2://%s@%s?%s#%s", url.PathEscape(str(m, "password")), host, query.Encode(), name)
+	case "tuic":
+		query := url.Values{}
+		if s := sni(m); s != "" {
+			query.Set("sni", s)
+		}
+		if alpn := strs(m, "alpn"); len(alpn) > 0 {
+			query.Set("alpn", strings.Join(alpn, ","))
+		}
+		if cc := str(m, "congestion-controller"); cc != "" {
+			query.Set("congestion_control", cc)
+		}
+		return fmt.Sprintf("tuic://%s:%s@%s?%s#%s", url.PathEscape(str(m, "uuid")), url.PathEscape(str(m, "password")), host, query.Encode(), name)
+	case "socks5":
+		userInfo := ""
+		if username := str(m, "username"); username != "" {
+			userInfo = base64.RawURLEncoding.EncodeToString([]byte(username+":"+str(m, "password"))) + "@"
+		}
+		return fmt.Sprintf("socks://%s%s#%s", userInfo, host, name)
+	}
+	return ""
+}
+
+// linkTransport 生成 vless/trojan 链接中通用的传输与TLS参数
+func linkTransport(m map[string]any) url.Values {
+	query := url.Values{}
+	network := str(m, "network")
+	if network == "" {
+		network = "tcp"
+	}
+	query.Set("type", network)
+	switch network {
+	case "ws":
+		if host := wsHost(m); host != "" {
+			query.Set("host", host)
+		}
+		if path := wsPath(m); path != "" {
+			query.Set("path", path)
+		}
+	case "grpc":
+		query.Set("serviceName", grpcService(m))
+	}
+	if s := sni(m); s != "" {
+		query.Set("sni", s)
+	}
+	if fp := str(m, "client-fingerprint"); fp != "" {
+		query.Set("fp", fp)
+	}
+	if alpn := strs(m, "alpn"); len(alpn) > 0 {
+		query.Set("alpn", strings.Join(alpn, ","))
+	}
+	if boolean(m, "skip-cert-verify") {
+		query.Set("allowInsecure", "1")
+	}
+	return query
+}
+
+func init() {
+	register(&V2Ray{})
+}
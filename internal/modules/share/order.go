@@ -0,0 +1,94 @@
+package share
+
+import (
+	"cmp"
+	"slices"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/share"
+)
+
+// 排序时按区间比较，避免测速的小幅波动导致客户端节点顺序频繁变化
+const (
+	delayBucket = 50   // ms
+	speedBucket = 1024 // KB/s
+	scoreBucket = 5
+)
+
+const (
+	scoreSpeedRef = 10240 // KB/s
+	scoreDelayRef = 1000  // ms
+)
+
+// nodeScore 综合速度、延迟与风险的评分 0-100
+func nodeScore(n *nodeModel.Data) uint32 {
+	score := 50 * min(float64(n.Info.SpeedDown.Average())/scoreSpeedRef, 1)
+	if delay := n.Info.Delay.Average(); delay > 0 {
+		score += 30 * (1 - min(float64(delay)/scoreDelayRef, 1))
+	}
+	score += 20 * (1 - min(float64(n.Info.Risk)/100, 1))
+	return uint32(score)
+}
+
+// orderNodes 按配置排序并截取节点，未指定排序字段时按节点标识排序以保证顺序稳定
+func orderNodes(nodes []nodeModel.Data, order share.OrderConfig) []nodeModel.Data {
+	slices.SortStableFunc(nodes, func(a, b nodeModel.Data) int {
+		for _, key := range order.Sort {
+			if c := compareBy(key, &a, &b); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.Base.UniqueKey, b.Base.UniqueKey)
+	})
+	if order.Reverse {
+		slices.Reverse(nodes)
+	}
+
+	if order.GroupBy != "" && order.GroupLimit > 0 {
+		counts := make(map[any]uint16)
+		result := nodes[:0]
+		for _, n := range nodes {
+			var key any
+			if order.GroupBy == share.GroupBySub {
+				key = n.Base.SubId
+			} else {
+				key = n.Info.Country
+			}
+			if counts[key] >= order.GroupLimit {
+				continue
+			}
+			counts[key]++
+			result = append(result, n)
+		}
+		nodes = result
+	}
+
+	if order.Limit > 0 && len(nodes) > int(order.Limit) {
+		nodes = nodes[:order.Limit]
+	}
+	return nodes
+}
+
+// compareBy 比较两个节点，优者在前
+func compareBy(key string, a, b *nodeModel.Data) int {
+	switch key {
+	case share.SortDelay:
+		return cmp.Compare(delayRank(a), delayRank(b))
+	case share.SortSpeed:
+		return cmp.Compare(b.Info.SpeedDown.Average()/speedBucket, a.Info.SpeedDown.Average()/speedBucket)
+	case share.SortScore:
+		return cmp.Compare(nodeScore(b)/scoreBucket, nodeScore(a)/scoreBucket)
+	case share.SortCountry:
+		return cmp.Compare(a.Info.Country, b.Info.Country)
+	}
+	return 0
+}
+
+// delayRank 未测得延迟的节点排在最后
+func delayRank(n *nodeModel.Data) uint16 {
+	delay := n.Info.Delay.Average()
+	if delay == 0 {
+		return ^uint16(0)
+	}
+	return delay / delayBucket
+}
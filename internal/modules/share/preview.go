@@ -0,0 +1,106 @@
+package share
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/modules/share/encoder"
+)
+
+// Preview 按分享配置预览节点，target 不为空时同时渲染对应格式的内容
+//
+// 预览不经过缓存和访问控制，也不计入访问次数
+func Preview(genConfig share.GenConfig, target string) share.Preview {
+	result := share.Preview{
+		Nodes:  []share.PreviewNode{},
+		Errors: []string{},
+	}
+	if err := node.ValidateFilter(genConfig.Filter); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("filter: %v", err))
+	}
+	if genConfig.Narrow != nil {
+		if err := node.ValidateFilter(*genConfig.Narrow); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("narrow: %v", err))
+		}
+	}
+	if err := genConfig.Order.Validate(); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("order: %v", err))
+	}
+	groupsErr := ValidateGroups(genConfig.Groups)
+	if groupsErr != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("groups: %v", groupsErr))
+	}
+	renameErr := ValidateRename(genConfig.Rename)
+	if renameErr != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("rename: %v", renameErr))
+	}
+	nodes, groups, err := genNodes(genConfig)
+	if err != nil && renameErr == nil && groupsErr == nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("rename: %v", err))
+	}
+	result.Total = len(nodes)
+	for _, n := range nodes {
+		protocol, _ := n.proxy.Map["type"].(string)
+		server, _ := n.proxy.Map["server"].(string)
+		port, _ := n.proxy.Map["port"].(int)
+		result.Nodes = append(result.Nodes, share.PreviewNode{
+			Name:       n.name,
+			OriginName: n.origin,
+			Protocol:   protocol,
+			Server:     server,
+			Port:       port,
+			Country:    n.country.NameZh,
+			SubName:    n.subName,
+			Delay:      n.delay,
+			SpeedDown:  n.speedDown,
+			Score:      n.score,
+		})
+	}
+	data := buildTemplateData(nodes, groups)
+	for _, g := range data.Countries {
+		result.Countries = append(result.Countries, share.PreviewGroup{Name: g.Name, Count: len(g.Proxies)})
+	}
+	for _, g := range data.Subs {
+		result.Subs = append(result.Subs, share.PreviewGroup{Name: g.Name, Count: len(g.Proxies)})
+	}
+	for _, g := range groups {
+		result.Groups = append(result.Groups, share.PreviewGroup{Name: g.Name, Count: len(g.Proxies)})
+	}
+	if target == "" || nodes == nil {
+		return result
+	}
+	if id := genConfig.Template.Get(target); id != 0 {
+		t, err := op.GetTemplateByID(context.Background(), id)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("template %d: %v", id, err))
+			return result
+		}
+		output, _, err := renderTemplate(t, nodes, groups)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("template %s: %v", t.Name, err))
+			return result
+		}
+		result.Output = string(output)
+		return result
+	}
+	enc, ok := encoder.Get(target)
+	if !ok {
+		result.Errors = append(result.Errors, fmt.Sprintf("unsupported target: %s", target))
+		return result
+	}
+	var output []byte
+	if groupEnc, ok := enc.(encoder.GroupEncoder); ok && len(groups) > 0 {
+		output, err = groupEnc.EncodeGroups(toProxies(nodes), groups)
+	} else {
+		output, err = enc.Encode(toProxies(nodes))
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("encode %s: %v", target, err))
+		return result
+	}
+	result.Output = string(output)
+	return result
+}
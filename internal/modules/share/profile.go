@@ -0,0 +1,47 @@
+package share
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/bestruirui/bestsub/internal/models/share"
+)
+
+// accessUnit 按访问次数折算流量时每次访问对应的字节数，客户端显示为 1GB
+const accessUnit = 1 << 30
+
+// ProfileHeaders 生成 subscription-userinfo 等客户端识别的响应头
+func ProfileHeaders(data *share.Data) map[string]string {
+	var genConfig share.GenConfig
+	json.Unmarshal([]byte(data.Gen), &genConfig)
+	profile := genConfig.Profile
+
+	headers := make(map[string]string, 3)
+	fileName := profile.FileName
+	if fileName == "" {
+		fileName = data.Name
+	}
+	if fileName != "" {
+		headers["Content-Disposition"] = "attachment; filename*=UTF-8''" + url.PathEscape(fileName)
+	}
+	if profile.UpdateInterval > 0 {
+		headers["Profile-Update-Interval"] = strconv.Itoa(int(profile.UpdateInterval))
+	}
+
+	upload, download, total := profile.Upload, profile.Download, profile.Total
+	if total == 0 && data.MaxAccessCount > 0 {
+		upload = 0
+		download = uint64(data.AccessCount) * accessUnit
+		total = uint64(data.MaxAccessCount) * accessUnit
+	}
+	if total > 0 || data.Expires > 0 {
+		userInfo := fmt.Sprintf("upload=%d; download=%d; total=%d", upload, download, total)
+		if data.Expires > 0 {
+			userInfo += fmt.Sprintf("; expire=%d", data.Expires)
+		}
+		headers["Subscription-Userinfo"] = userInfo
+	}
+	return headers
+}
@@ -0,0 +1,188 @@
+package share
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rename 修改节点的 name 字段
+//
+// 节点通常是单行的 flow 风格 YAML，直接定位 name 的值并替换，其余内容保持原样；
+// 无法识别时解析为 YAML 节点后重新编码
+func rename(raw []byte, newName string) []byte {
+	if out, ok := renameFlow(raw, newName); ok {
+		return out
+	}
+	out, err := renameNode(raw, newName)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// renameFlow 扫描顶层 flow mapping，替换 name 的值，没有 name 时插入到开头
+func renameFlow(raw []byte, newName string) ([]byte, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) < 2 || raw[0] != '{' || raw[len(raw)-1] != '}' {
+		return nil, false
+	}
+	var (
+		depth      int
+		entryStart = 1
+		keyEnd     = -1
+		valStart   = -1
+		tokenStart = true
+	)
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		if tokenStart {
+			if c == ' ' || c == '\t' {
+				continue
+			}
+			if c == '"' || c == '\'' {
+				end := skipQuoted(raw, i)
+				if end < 0 {
+					return nil, false
+				}
+				i = end
+				tokenStart = false
+				continue
+			}
+		}
+		tokenStart = false
+		switch c {
+		case '{', '[':
+			depth++
+			tokenStart = true
+		case ',', '}', ']':
+			if depth > 0 {
+				if c != ',' {
+					depth--
+				} else {
+					tokenStart = true
+				}
+				continue
+			}
+			if c == ']' {
+				return nil, false
+			}
+			if keyEnd > 0 && unquoteKey(raw[entryStart:keyEnd]) == "name" {
+				return replaceSpan(raw, valStart, i, " "+quoteName(newName)), true
+			}
+			if c == '}' {
+				if i != len(raw)-1 {
+					return nil, false
+				}
+				return replaceSpan(raw, 1, 1, "name: "+quoteName(newName)+", "), true
+			}
+			entryStart, keyEnd, valStart = i+1, -1, -1
+			tokenStart = true
+		case ':':
+			if i+1 < len(raw) && !isFlowSep(raw[i+1]) {
+				continue
+			}
+			tokenStart = true
+			if depth == 0 && keyEnd < 0 {
+				keyEnd, valStart = i, i+1
+			}
+		}
+	}
+	return nil, false
+}
+
+// renameNode 解析为 YAML 节点修改 name 后重新编码，保留原有的 flow 风格
+func renameNode(raw []byte, newName string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("node is not a mapping")
+	}
+	root := doc.Content[0]
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: newName, Style: yaml.DoubleQuotedStyle}
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "name" {
+			root.Content[i+1] = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+	}
+	root.Style = yaml.FlowStyle
+	out, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(out), nil
+}
+
+// skipQuoted 返回从 start 开始的引号字符串结束位置
+func skipQuoted(raw []byte, start int) int {
+	quote := raw[start]
+	for i := start + 1; i < len(raw); i++ {
+		switch {
+		case quote == '"' && raw[i] == '\\':
+			i++
+		case raw[i] == quote:
+			if quote == '\'' && i+1 < len(raw) && raw[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func isFlowSep(c byte) bool {
+	return c == ' ' || c == '\t' || c == ',' || c == '}' || c == ']'
+}
+
+func unquoteKey(key []byte) string {
+	key = bytes.TrimSpace(key)
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		key = key[1 : len(key)-1]
+	}
+	return string(key)
+}
+
+func replaceSpan(raw []byte, start, end int, value string) []byte {
+	out := make([]byte, 0, len(raw)+len(value)+1)
+	out = append(out, raw[:start]...)
+	out = append(out, value...)
+	out = append(out, raw[end:]...)
+	return out
+}
+
+// quoteName 生成 YAML 双引号字符串，只转义控制字符，国旗等表情原样保留
+func quoteName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name) + 2)
+	b.WriteByte('"')
+	for _, r := range name {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\x%02X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
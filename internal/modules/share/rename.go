@@ -0,0 +1,215 @@
+package share
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/bestruirui/bestsub/internal/utils/country"
+)
+
+type renameTmpl struct {
+	SpeedUp       uint32
+	SpeedDown     uint32
+	Delay         uint32
+	Risk          uint32
+	Score         uint32
+	Country       country.Country
+	Region        country.Country
+	Count         uint32
+	IP            string
+	SubName       string
+	SubTags       string
+	SubTagsOrigin []string
+	Protocol      string
+	Port          int
+	OriginName    string
+}
+
+var renameFuncs = template.FuncMap{
+	"add": func(x, y uint32) uint32 {
+		return x + y
+	},
+	"sub": func(x, y uint32) uint32 {
+		return x - y
+	},
+	"mul": func(x, y uint32) uint32 {
+		return x * y
+	},
+	"div": func(x, y uint32) uint32 {
+		if y == 0 {
+			return 0
+		}
+		return x / y
+	},
+	"mod": func(x, y uint32) uint32 {
+		if y == 0 {
+			return 0
+		}
+		return x % y
+	},
+	// mbps 将 KB/s 换算为 MB/s，默认保留一位小数
+	"mbps": func(kb uint32, precision ...int) string {
+		p := 1
+		if len(precision) > 0 && precision[0] >= 0 {
+			p = precision[0]
+		}
+		return strconv.FormatFloat(float64(kb)/1024, 'f', p, 64)
+	},
+	// cond 条件为真时返回 a，否则返回 b
+	"cond": func(ok bool, a, b any) any {
+		if ok {
+			return a
+		}
+		return b
+	},
+	// level 按阈值从小到大分级：level .Delay 50 "🚀" 100 "⚡" "🐢"
+	"level": func(value uint32, args ...any) (any, error) {
+		for len(args) >= 2 {
+			threshold, err := toUint32(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if value <= threshold {
+				return args[1], nil
+			}
+			args = args[2:]
+		}
+		if len(args) == 1 {
+			return args[0], nil
+		}
+		return "", nil
+	},
+	// default 值为空时使用默认值
+	"default": func(def, value any) any {
+		if value == nil || fmt.Sprint(value) == "" || fmt.Sprint(value) == "0" {
+			return def
+		}
+		return value
+	},
+	"padLeft": func(width int, pad string, value any) string {
+		return padString(fmt.Sprint(value), width, pad, true)
+	},
+	"padRight": func(width int, pad string, value any) string {
+		return padString(fmt.Sprint(value), width, pad, false)
+	},
+	// truncate 按字符截断，超出部分以 … 结尾
+	"truncate": func(length int, value string) string {
+		if length <= 0 || utf8.RuneCountInString(value) <= length {
+			return value
+		}
+		runes := []rune(value)
+		return string(runes[:length-1]) + "…"
+	},
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": func(old, new, value string) string { return strings.ReplaceAll(value, old, new) },
+	"join":    func(sep string, values []string) string { return strings.Join(values, sep) },
+}
+
+// parseRename 解析重命名模板，每次解析都使用独立的模板避免并发修改
+func parseRename(text string) (*template.Template, error) {
+	return template.New("node").Funcs(renameFuncs).Parse(text)
+}
+
+// ValidateRename 使用示例节点执行重命名模板，检查语法与变量
+func ValidateRename(text string) error {
+	if text == "" {
+		return nil
+	}
+	tmpl, err := parseRename(text)
+	if err != nil {
+		return fmt.Errorf("parse rename template: %w", err)
+	}
+	sample := renameTmpl{
+		SpeedUp:       1024,
+		SpeedDown:     10240,
+		Delay:         80,
+		Risk:          1,
+		Score:         80,
+		Country:       country.Country{NameEn: "US", NameZh: "美国", Emoji: "🇺🇸"},
+		Region:        country.Country{NameEn: "US", NameZh: "美国", Emoji: "🇺🇸"},
+		IP:            "1.1.1.1",
+		SubName:       "sample",
+		SubTags:       "<sample>",
+		SubTagsOrigin: []string{"sample"},
+		Protocol:      "trojan",
+		Port:          443,
+		Count:         1,
+		OriginName:    "US 01",
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, sample); err != nil {
+		return fmt.Errorf("execute rename template: %w", err)
+	}
+	if strings.TrimSpace(buf.String()) == "" {
+		return fmt.Errorf("rename template produces empty name")
+	}
+	return nil
+}
+
+// fallbackName 重命名失败时的节点名称：原始名称、地区加序号、协议加序号
+func fallbackName(info *renameTmpl) string {
+	if name := strings.TrimSpace(info.OriginName); name != "" {
+		return name
+	}
+	region := info.Country
+	if region.NameEn == "" {
+		region = info.Region
+	}
+	if region.NameEn != "" {
+		return fmt.Sprintf("%s%s %02d", region.Emoji, region.NameZh, info.Count)
+	}
+	if info.Protocol != "" {
+		return fmt.Sprintf("%s %02d", info.Protocol, info.Count)
+	}
+	return fmt.Sprintf("node %02d", info.Count)
+}
+
+// uniqueName 客户端要求节点名称唯一，重复的名称依次追加序号
+func uniqueName(seen map[string]int, name string) string {
+	n := seen[name]
+	seen[name] = n + 1
+	if n == 0 {
+		return name
+	}
+	for {
+		n++
+		candidate := fmt.Sprintf("%s %d", name, n)
+		if seen[candidate] == 0 {
+			seen[candidate] = 1
+			seen[name] = n
+			return candidate
+		}
+	}
+}
+
+func padString(s string, width int, pad string, left bool) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 || pad == "" {
+		return s
+	}
+	fill := strings.Repeat(pad, n)
+	fill = string([]rune(fill)[:n])
+	if left {
+		return fill + s
+	}
+	return s + fill
+}
+
+func toUint32(v any) (uint32, error) {
+	switch t := v.(type) {
+	case int:
+		return uint32(t), nil
+	case uint32:
+		return t, nil
+	case int64:
+		return uint32(t), nil
+	case float64:
+		return uint32(t), nil
+	}
+	return 0, fmt.Errorf("invalid threshold: %v", v)
+}
@@ -7,143 +7,332 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
-	"text/template"
 
-	"github.com/bestruirui/bestsub/internal/config"
 	"github.com/bestruirui/bestsub/internal/core/mihomo"
 	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
 	"github.com/bestruirui/bestsub/internal/models/setting"
 	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/modules/share/encoder"
 	"github.com/bestruirui/bestsub/internal/modules/subcer"
 	"github.com/bestruirui/bestsub/internal/utils"
 	"github.com/bestruirui/bestsub/internal/utils/country"
+	"github.com/bestruirui/bestsub/internal/utils/log"
 	"github.com/google/go-querystring/query"
+	"gopkg.in/yaml.v3"
 )
 
-func GenSubData(genConfigStr string, userAgent string, token string, extraQuery string) []byte {
+// Result 生成的分享内容
+type Result struct {
+	Data      []byte
+	Format    string
+	NodeCount int // 通过 subconverter 生成时为0
+}
+
+// subQueryKeys 客户端可以传给 subconverter 的参数，其他参数会被忽略，也不参与缓存
+var subQueryKeys = []string{
+	"target", "ver", "config", "emoji", "add_emoji", "remove_emoji", "append_type", "append_info",
+	"list", "udp", "tfo", "scv", "tls13", "fdn", "sort", "include", "exclude", "rename",
+	"filename", "interval", "strict", "expand", "classic", "insert", "prepend", "new_name",
+}
+
+// SubQuery 只保留影响 subconverter 输出的查询参数，按参数名排序
+func SubQuery(rawQuery string) string {
+	values, _ := url.ParseQuery(rawQuery)
+	kept := url.Values{}
+	for _, key := range subQueryKeys {
+		if v, ok := values[key]; ok {
+			kept[key] = v
+		}
+	}
+	return kept.Encode()
+}
+
+// SubVariant 带规则订阅的缓存标识，由实际输出格式与 SubQuery 处理后的参数组成
+func SubVariant(genConfigStr string, userAgent string, extraQuery string) string {
 	var genConfig share.GenConfig
 	if err := json.Unmarshal([]byte(genConfigStr), &genConfig); err != nil {
-		return nil
+		return extraQuery
+	}
+	return subTarget(genConfig, userAgent, extraQuery) + "\n" + extraQuery
+}
+
+// GenSubData 生成带规则的订阅，extraQuery 需经过 SubQuery 处理
+func GenSubData(genConfigStr string, userAgent string, token string, extraQuery string) Result {
+	var genConfig share.GenConfig
+	if err := json.Unmarshal([]byte(genConfigStr), &genConfig); err != nil {
+		return Result{}
+	}
+	target := subTarget(genConfig, userAgent, extraQuery)
+	result := Result{Format: target}
+	if id := genConfig.Template.Get(target); id != 0 {
+		data, count, err := GenTemplateData(genConfig, id)
+		if err != nil {
+			log.Warnf("render share template %d failed: %v", id, err)
+			return result
+		}
+		result.Data, result.NodeCount = data, count
+		return result
 	}
 	subUrlParam, _ := query.Values(genConfig.SubConverter)
+	subUrlParam.Set("target", target)
+	if extra, err := url.ParseQuery(extraQuery); err == nil {
+		extra.Del("target")
+		extraQuery = extra.Encode()
+	}
 	if genConfig.Proxy {
 		subUrlParam.Add("config_proxy", op.GetSettingStr(setting.PROXY_URL))
 	}
-	subUrlParam.Add("url", fmt.Sprintf("http://127.0.0.1:%d/api/v1/share/node/%s", config.Base().Server.Port, token))
+	callback, release := subcer.NewCallback()
+	defer release()
+	subUrlParam.Add("url", fmt.Sprintf("%s/api/v1/share/node/%s?target=clash&callback=%s", subcer.CallbackUrl(), token, callback))
 	subUrlParam.Add("remove_emoji", "false")
 	subcer.RLock()
 	defer subcer.RUnlock()
 	requestUrl := fmt.Sprintf("%s/sub?%s&%s", subcer.GetBaseUrl(), subUrlParam.Encode(), extraQuery)
 	client := mihomo.Default(false)
 	if client == nil {
-		return nil
+		return result
 	}
 	request, err := http.NewRequest("GET", requestUrl, nil)
 	if err != nil {
-		return nil
+		return result
 	}
 	request.Header.Set("User-Agent", userAgent)
 	response, err := client.Do(request)
 	if err != nil {
-		return nil
+		return result
 	}
 	defer response.Body.Close()
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil
+		return result
 	}
-	return body
+	result.Data = body
+	return result
 }
 
-func GenNodeData(config string) []byte {
+// GenNodeData 使用内置编码器渲染节点，enc 为空时输出 Mihomo 格式
+func GenNodeData(config string, enc encoder.Encoder) Result {
+	if enc == nil {
+		enc, _ = encoder.Get("clash")
+	}
+	result := Result{Format: enc.Name()}
 	var genConfig share.GenConfig
 	if err := json.Unmarshal([]byte(config), &genConfig); err != nil {
-		return nil
+		return result
+	}
+	nodes, groups, err := genNodes(genConfig)
+	if err != nil {
+		log.Warnf("generate share nodes failed: %v", err)
+		if nodes == nil {
+			return result
+		}
+	}
+	var data []byte
+	if groupEnc, ok := enc.(encoder.GroupEncoder); ok && len(groups) > 0 {
+		data, err = groupEnc.EncodeGroups(toProxies(nodes), groups)
+	} else {
+		data, err = enc.Encode(toProxies(nodes))
 	}
-	nodes := node.GetByFilter(genConfig.Filter)
-	var result bytes.Buffer
-	result.Write(nodeData)
-	tmpl, err := renameTemplate.Parse(genConfig.Rename)
 	if err != nil {
-		return nil
+		log.Warnf("encode share nodes to %s failed: %v", enc.Name(), err)
+		return result
+	}
+	result.Data, result.NodeCount = data, len(nodes)
+	return result
+}
+
+type shareNode struct {
+	key       uint64
+	proxy     encoder.Proxy
+	name      string
+	origin    string
+	country   country.Country
+	subName   string
+	speedDown uint32
+	delay     uint32
+	score     uint32
+}
+
+// ValidateGroups 检查分享分组的配置、过滤条件与重命名模板，分组名称不能重复
+func ValidateGroups(groups []share.GroupConfig) error {
+	names := make(map[string]struct{}, len(groups))
+	for i := range groups {
+		g := &groups[i]
+		if err := g.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[g.Name]; ok {
+			return fmt.Errorf("duplicate group name: %s", g.Name)
+		}
+		names[g.Name] = struct{}{}
+		if err := node.ValidateFilter(g.Filter); err != nil {
+			return fmt.Errorf("group %s: %w", g.Name, err)
+		}
+		if err := ValidateRename(g.Rename); err != nil {
+			return fmt.Errorf("group %s: %w", g.Name, err)
+		}
 	}
+	return nil
+}
+
+// genNodes 筛选并重命名节点，配置了分组时节点为各分组的并集，并返回每个分组对应的代理组
+//
+// 配置了分组时，分享的过滤条件对每个分组生效，分享的节点上限作用于所有分组的并集，
+// 与分组同名的节点会加上序号。重命名模板解析失败的分组没有节点，所有分组都失败时返回 nil
+func genNodes(genConfig share.GenConfig) ([]shareNode, []encoder.Group, error) {
+	sections := genConfig.Groups
+	if len(sections) == 0 {
+		sections = []share.GroupConfig{{Filter: genConfig.Filter, Rename: genConfig.Rename, Order: genConfig.Order}}
+	}
+	type emittedKey struct {
+		key  uint64
+		name string
+	}
+	var firstErr error
+	failed := 0
+	result := make([]shareNode, 0)
+	groups := make([]encoder.Group, 0, len(sections))
+	names := make(map[string]int)
+	for _, g := range genConfig.Groups {
+		names[g.Name] = 1
+	}
+	emitted := make(map[emittedKey]string)
+	limit := int(genConfig.Order.Limit)
+	for i := range sections {
+		section := &sections[i]
+		renameText := section.Rename
+		if renameText == "" {
+			renameText = genConfig.Rename
+		}
+		filters := []nodeModel.Filter{section.Filter}
+		if len(genConfig.Groups) > 0 {
+			filters = append(filters, genConfig.Filter)
+		}
+		if genConfig.Narrow != nil {
+			filters = append(filters, *genConfig.Narrow)
+		}
+		nodes, err := sectionNodes(filters, renameText, section.Order)
+		if nodes == nil {
+			failed++
+		}
+		if err != nil && firstErr == nil {
+			if len(genConfig.Groups) > 0 {
+				err = fmt.Errorf("group %s: %w", section.Name, err)
+			}
+			firstErr = err
+		}
+		group := encoder.Group{Name: section.Name, Type: section.GroupType()}
+		for _, n := range nodes {
+			k := emittedKey{n.key, n.name}
+			if name, ok := emitted[k]; ok {
+				group.Proxies = append(group.Proxies, name)
+				continue
+			}
+			if limit > 0 && len(result) >= limit {
+				continue
+			}
+			n.name = uniqueName(names, n.name)
+			emitted[k] = n.name
+			if n.name != n.origin {
+				n.proxy.Raw = rename(n.proxy.Raw, n.name)
+				if n.proxy.Map != nil {
+					n.proxy.Map["name"] = n.name
+				}
+			}
+			result = append(result, n)
+			group.Proxies = append(group.Proxies, n.name)
+		}
+		groups = append(groups, group)
+	}
+	if failed == len(sections) {
+		return nil, nil, firstErr
+	}
+	if len(genConfig.Groups) == 0 {
+		groups = nil
+	}
+	return result, groups, firstErr
+}
+
+// sectionNodes 按过滤、重命名与排序条件生成节点，节点需满足所有过滤条件，名称尚未去重
+//
+// 重命名模板执行出错或输出为空的节点使用 fallbackName，报告第一个错误
+func sectionNodes(filters []nodeModel.Filter, renameText string, order share.OrderConfig) ([]shareNode, error) {
+	tmpl, err := parseRename(renameText)
+	if err != nil {
+		return nil, fmt.Errorf("parse rename template: %w", err)
+	}
+	nodes := orderNodes(*node.GetByFilters(filters...), order)
+	var execErr error
+	result := make([]shareNode, 0, len(nodes))
 	var newName bytes.Buffer
-	for i, node := range *nodes {
-		newName.Reset()
-		result.Write(dash)
+	for i, node := range nodes {
+		var m map[string]any
+		if err := yaml.Unmarshal(node.Base.Raw, &m); err != nil {
+			log.Debugf("parse share node failed: %v", err)
+		}
+		originName := node.Base.Name
+		if originName == "" {
+			originName, _ = m["name"].(string)
+		}
+		protocol, _ := m["type"].(string)
+		port, _ := m["port"].(int)
 		subTags := op.GetSubTagsByID(context.Background(), node.Base.SubId)
 		simpleInfo := renameTmpl{
 			SpeedUp:       node.Info.SpeedUp.Average(),
 			SpeedDown:     node.Info.SpeedDown.Average(),
 			Delay:         uint32(node.Info.Delay.Average()),
 			Risk:          uint32(node.Info.Risk),
+			Score:         nodeScore(&node),
 			Count:         uint32(i + 1),
 			Country:       country.GetCountry(node.Info.Country),
+			Region:        country.GetCountry(node.Base.Region),
 			IP:            utils.Uint32ToIP(node.Info.IP),
 			SubName:       op.GetSubNameByID(context.Background(), node.Base.SubId),
 			SubTags:       fmt.Sprintf("<%s>", strings.Join(subTags, "|")),
 			SubTagsOrigin: subTags,
+			Protocol:      protocol,
+			Port:          port,
+			OriginName:    originName,
+		}
+		name := originName
+		if renameText != "" {
+			newName.Reset()
+			err := tmpl.Execute(&newName, simpleInfo)
+			if err != nil && execErr == nil {
+				execErr = fmt.Errorf("execute rename template: %w", err)
+			}
+			name = strings.TrimSpace(newName.String())
+			if err != nil {
+				name = ""
+			}
+		}
+		if name == "" {
+			name = fallbackName(&simpleInfo)
 		}
-		tmpl.Execute(&newName, simpleInfo)
-		result.Write(rename(node.Base.Raw, newName.Bytes()))
-		result.Write(newLine)
+		result = append(result, shareNode{
+			key:       node.Base.UniqueKey,
+			proxy:     encoder.Proxy{Raw: node.Base.Raw, Map: m},
+			name:      name,
+			origin:    originName,
+			country:   simpleInfo.Country,
+			subName:   simpleInfo.SubName,
+			speedDown: simpleInfo.SpeedDown,
+			delay:     simpleInfo.Delay,
+			score:     simpleInfo.Score,
+		})
 	}
-	return result.Bytes()
+	return result, execErr
 }
 
-func rename(raw []byte, newName []byte) []byte {
-	idx := bytes.Index(raw, serverDelim)
-	if idx < 0 {
-		return raw
-	}
-	out := make([]byte, 0, len(name)+len(newName)+len(raw)-idx)
-	out = append(out, name...)
-	out = append(out, newName...)
-	out = append(out, raw[idx:]...)
-	return out
-}
-
-var (
-	name        = []byte("{name: ")
-	serverDelim = []byte(", server:")
-
-	nodeData = []byte("proxies:\n")
-	newLine  = []byte("\n")
-	dash     = []byte(" - ")
-)
-
-type renameTmpl struct {
-	SpeedUp       uint32
-	SpeedDown     uint32
-	Delay         uint32
-	Risk          uint32
-	Country       country.Country
-	Count         uint32
-	IP            string
-	SubName       string
-	SubTags       string
-	SubTagsOrigin []string
+func toProxies(nodes []shareNode) []encoder.Proxy {
+	proxies := make([]encoder.Proxy, len(nodes))
+	for i := range nodes {
+		proxies[i] = nodes[i].proxy
+	}
+	return proxies
 }
-
-var renameTemplate = template.New("node").Funcs(template.FuncMap{
-	"add": func(x, y uint32) uint32 {
-		return x + y
-	},
-	"sub": func(x, y uint32) uint32 {
-		return x - y
-	},
-	"div": func(x, y uint32) uint32 {
-		if y == 0 {
-			return 0
-		}
-		return x / y
-	},
-	"mod": func(x, y uint32) uint32 {
-		if y == 0 {
-			return 0
-		}
-		return x % y
-	},
-})
@@ -0,0 +1,133 @@
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/config"
+	"github.com/bestruirui/bestsub/internal/models/share"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid share signature")
+	ErrSignedExpired    = errors.New("signed share expired")
+)
+
+const defaultSignedTTL = 24 * time.Hour
+
+// Sign 为分享生成带过期时间的签名 token，token 由参数与 HMAC 签名组成，校验时不需要额外的数据库记录
+func Sign(data *share.Data, req share.SignRequest) (string, share.SignedClaims) {
+	ttl := time.Duration(req.TTL) * time.Second
+	if ttl == 0 {
+		ttl = defaultSignedTTL
+	}
+	claims := share.SignedClaims{
+		ShareID: data.ID,
+		Expires: time.Now().Add(ttl).Unix(),
+		Limit:   req.Limit,
+		Filter:  req.Filter,
+	}
+	if data.Expires > 0 && claims.Expires > int64(data.Expires) {
+		claims.Expires = int64(data.Expires)
+	}
+	payload := compactJSON(claims)
+	// 返回 token 中实际携带的参数
+	var signed share.SignedClaims
+	if err := json.Unmarshal(payload, &signed); err == nil {
+		claims = signed
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signature(encoded, data.Token)), claims
+}
+
+// ParseSigned 解析签名 token 中的参数并检查是否过期，签名由 ApplySigned 校验
+func ParseSigned(token string) (*share.SignedClaims, error) {
+	encoded, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	var claims share.SignedClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if claims.Expires < time.Now().Unix() {
+		return nil, ErrSignedExpired
+	}
+	return &claims, nil
+}
+
+// ApplySigned 使用分享当前的 token 校验签名，返回附加了过滤条件与数量限制的分享副本
+func ApplySigned(data *share.Data, token string, claims *share.SignedClaims) (*share.Data, error) {
+	encoded, sig, _ := strings.Cut(token, ".")
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || claims.ShareID != data.ID || !hmac.Equal(got, signature(encoded, data.Token)) {
+		return nil, ErrInvalidSignature
+	}
+	if claims.Filter == nil && claims.Limit == 0 {
+		return data, nil
+	}
+	var genConfig share.GenConfig
+	if err := json.Unmarshal([]byte(data.Gen), &genConfig); err != nil {
+		return nil, err
+	}
+	genConfig.Narrow = claims.Filter
+	// 分享的节点上限作用于输出的全部节点，配置了分组时为各分组的并集
+	if claims.Limit > 0 && (genConfig.Order.Limit == 0 || genConfig.Order.Limit > claims.Limit) {
+		genConfig.Order.Limit = claims.Limit
+	}
+	gen, err := json.Marshal(genConfig)
+	if err != nil {
+		return nil, fmt.Errorf("marshal signed share config: %w", err)
+	}
+	narrowed := *data
+	narrowed.Gen = string(gen)
+	return &narrowed, nil
+}
+
+func signature(payload string, shareToken string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.Base().JWT.Secret))
+	mac.Write([]byte("share:"))
+	mac.Write([]byte(shareToken))
+	mac.Write([]byte{'.'})
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// compactJSON 去掉 null 与空数组以缩短 token，解析时缺少的字段即为零值
+//
+// 其他值都保留，指针字段的 false 与未设置含义不同
+func compactJSON(v any) []byte {
+	b, _ := json.Marshal(v)
+	var m map[string]any
+	if json.Unmarshal(b, &m) != nil {
+		return b
+	}
+	dropEmpty(m)
+	out, _ := json.Marshal(m)
+	return out
+}
+
+func dropEmpty(m map[string]any) {
+	for k, v := range m {
+		switch t := v.(type) {
+		case nil:
+			delete(m, k)
+		case map[string]any:
+			dropEmpty(t)
+		case []any:
+			if len(t) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package share
+
+import (
+	"testing"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/share"
+)
+
+func TestSignKeepsFalseFilter(t *testing.T) {
+	tls := false
+	data := &share.Data{ID: 1, Token: "share-token", Gen: "{}"}
+	token, claims := Sign(data, share.SignRequest{Filter: &nodeModel.Filter{TLS: &tls}})
+	if claims.Filter == nil || claims.Filter.TLS == nil || *claims.Filter.TLS {
+		t.Fatalf("sign dropped tls filter: %+v", claims.Filter)
+	}
+	parsed, err := ParseSigned(token)
+	if err != nil {
+		t.Fatalf("parse signed token: %v", err)
+	}
+	if parsed.ShareID != data.ID || parsed.Filter == nil || parsed.Filter.TLS == nil || *parsed.Filter.TLS {
+		t.Fatalf("tls filter lost after round trip: %+v", parsed.Filter)
+	}
+	narrowed, err := ApplySigned(data, token, parsed)
+	if err != nil {
+		t.Fatalf("apply signed token: %v", err)
+	}
+	if narrowed == data {
+		t.Fatal("signed filter not applied")
+	}
+}
@@ -0,0 +1,299 @@
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/modules/share/encoder"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateGroup 模板中自动生成的节点分组
+type TemplateGroup struct {
+	Name    string
+	Type    string // 分享分组的代理组类型，国家与订阅分组为空
+	Proxies []string
+}
+
+func (g TemplateGroup) group(groupType string) encoder.Group {
+	if groupType == "" {
+		groupType = g.Type
+	}
+	if groupType == "" {
+		groupType = share.GroupTypeSelect
+	}
+	return encoder.Group{Name: g.Name, Type: groupType, Proxies: g.Proxies}
+}
+
+// templateData 渲染规则模板时可用的数据
+type templateData struct {
+	All       []string
+	Countries []TemplateGroup
+	Subs      []TemplateGroup
+	Groups    []TemplateGroup // 分享中配置的分组
+
+	bySpeed []string
+}
+
+// Fastest 下载速度最快的 n 个节点
+func (d *templateData) Fastest(n int) []string {
+	if n <= 0 || n > len(d.bySpeed) {
+		n = len(d.bySpeed)
+	}
+	return d.bySpeed[:n]
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// names 获取分组名称
+	"names": func(groups []TemplateGroup) []string {
+		result := make([]string, len(groups))
+		for i, g := range groups {
+			result[i] = g.Name
+		}
+		return result
+	},
+	// concat 将字符串与字符串数组拼接为一个数组
+	"concat": func(values ...any) []string {
+		var result []string
+		for _, v := range values {
+			switch t := v.(type) {
+			case string:
+				result = append(result, t)
+			case []string:
+				result = append(result, t...)
+			}
+		}
+		return result
+	},
+	// clashGroups 为每个分组生成一行 proxy-groups 配置，groupType 为空时使用分组自身的类型
+	"clashGroups": func(groupType string, groups []TemplateGroup) (string, error) {
+		var buf strings.Builder
+		for _, g := range groups {
+			line, err := encoder.ClashGroup(g.group(groupType))
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString("  - ")
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		return buf.String(), nil
+	},
+	// singboxGroups 为每个分组生成一个 outbound，每项以逗号结尾，groupType 为空时使用分组自身的类型
+	"singboxGroups": func(groupType string, groups []TemplateGroup) (string, error) {
+		var buf strings.Builder
+		for _, g := range groups {
+			b, err := json.Marshal(encoder.SingBoxGroup(g.group(groupType)))
+			if err != nil {
+				return "", err
+			}
+			buf.Write(b)
+			buf.WriteString(",\n")
+		}
+		return buf.String(), nil
+	},
+}
+
+// subTarget 确定带规则订阅的输出格式
+func subTarget(genConfig share.GenConfig, userAgent string, extraQuery string) string {
+	if values, err := url.ParseQuery(extraQuery); err == nil && values.Get("target") != "" {
+		return values.Get("target")
+	}
+	if genConfig.SubConverter.Target != "" {
+		return genConfig.SubConverter.Target
+	}
+	if enc, ok := encoder.Detect(userAgent); ok {
+		return enc.Name()
+	}
+	return share.TemplateTypeClash
+}
+
+// GenTemplateData 使用规则模板在本地生成完整配置
+func GenTemplateData(genConfig share.GenConfig, templateID uint16) ([]byte, int, error) {
+	t, err := op.GetTemplateByID(context.Background(), templateID)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodes, groups, err := genNodes(genConfig)
+	if nodes == nil {
+		return nil, 0, err
+	}
+	return renderTemplate(t, nodes, groups)
+}
+
+// ValidateTemplate 使用示例节点渲染模板，检查语法与输出格式
+func ValidateTemplate(t *share.Template) error {
+	sample := []shareNode{
+		{name: "🇺🇸 US 01", subName: "sample", speedDown: 2048, proxy: encoder.Proxy{
+			Raw: []byte(`{name: "🇺🇸 US 01", server: 1.1.1.1, port: 443, type: trojan, password: sample}`),
+			Map: map[string]any{"name": "🇺🇸 US 01", "server": "1.1.1.1", "port": 443, "type": "trojan", "password": "sample"},
+		}},
+	}
+	sample[0].country.NameZh, sample[0].country.Emoji = "美国", "🇺🇸"
+	groups := []encoder.Group{{Name: "sample", Type: share.GroupTypeURLTest, Proxies: []string{sample[0].name}}}
+	_, _, err := renderTemplate(t, sample, groups)
+	return err
+}
+
+// ValidateTemplateConfig 检查分享引用的模板存在，且类型与所在的输出格式一致
+func ValidateTemplateConfig(ctx context.Context, c share.TemplateConfig) error {
+	for _, typ := range []string{share.TemplateTypeClash, share.TemplateTypeSingBox} {
+		id := c.Get(typ)
+		if id == 0 {
+			continue
+		}
+		t, err := op.GetTemplateByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s template %d not found", typ, id)
+		}
+		if t.Type != typ {
+			return fmt.Errorf("template %d is a %s template, cannot be used as %s template", id, t.Type, typ)
+		}
+	}
+	return nil
+}
+
+// TemplateUsers 返回引用了模板的分享名称
+func TemplateUsers(ctx context.Context, id uint16) ([]string, error) {
+	shares, err := op.GetShareList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range shares {
+		var genConfig share.GenConfig
+		if err := json.Unmarshal([]byte(s.Gen), &genConfig); err != nil {
+			continue
+		}
+		if genConfig.Template.Clash == id || genConfig.Template.SingBox == id {
+			names = append(names, s.Name)
+		}
+	}
+	return names, nil
+}
+
+// renderTemplate 渲染模板，返回内容与实际输出的节点数
+func renderTemplate(t *share.Template, nodes []shareNode, groups []encoder.Group) ([]byte, int, error) {
+	tmpl, err := template.New(t.Name).Funcs(templateFuncs).Parse(t.Template)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parse template: %w", err)
+	}
+	switch t.Type {
+	case share.TemplateTypeClash:
+		return renderClash(tmpl, nodes, groups)
+	case share.TemplateTypeSingBox:
+		return renderSingBox(tmpl, nodes, groups)
+	}
+	return nil, 0, fmt.Errorf("unsupported template type: %s", t.Type)
+}
+
+func renderClash(tmpl *template.Template, nodes []shareNode, groups []encoder.Group) ([]byte, int, error) {
+	enc, _ := encoder.Get(share.TemplateTypeClash)
+	proxies, err := enc.Encode(toProxies(nodes))
+	if err != nil {
+		return nil, 0, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, buildTemplateData(nodes, groups)); err != nil {
+		return nil, 0, fmt.Errorf("execute template: %w", err)
+	}
+	var check map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &check); err != nil {
+		return nil, 0, fmt.Errorf("template output is not valid yaml: %w", err)
+	}
+	if _, ok := check["proxies"]; ok {
+		return nil, 0, fmt.Errorf("template must not contain proxies, they are generated")
+	}
+	return append(proxies, buf.Bytes()...), len(nodes), nil
+}
+
+func renderSingBox(tmpl *template.Template, nodes []shareNode, groups []encoder.Group) ([]byte, int, error) {
+	outbounds := make([]any, 0, len(nodes))
+	supported := make([]shareNode, 0, len(nodes))
+	for _, n := range nodes {
+		if outbound := encoder.SingBoxOutbound(n.proxy.Map); outbound != nil {
+			outbounds = append(outbounds, outbound)
+			supported = append(supported, n)
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, buildTemplateData(supported, groups)); err != nil {
+		return nil, 0, fmt.Errorf("execute template: %w", err)
+	}
+	var config map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		return nil, 0, fmt.Errorf("template output is not valid json: %w", err)
+	}
+	existing, _ := config["outbounds"].([]any)
+	config["outbounds"] = append(existing, outbounds...)
+	data, err := json.MarshalIndent(config, "", "  ")
+	return data, len(supported), err
+}
+
+// buildTemplateData 生成模板数据，分享分组中只保留 nodes 内的节点，没有节点的分组会被忽略
+func buildTemplateData(nodes []shareNode, groups []encoder.Group) *templateData {
+	data := &templateData{
+		All:     make([]string, 0, len(nodes)),
+		bySpeed: make([]string, 0, len(nodes)),
+	}
+	countryIdx := make(map[string]int)
+	subIdx := make(map[string]int)
+	for _, n := range nodes {
+		data.All = append(data.All, n.name)
+
+		countryName := strings.TrimSpace(n.country.Emoji + " " + n.country.NameZh)
+		if countryName == "" {
+			countryName = "其他地区"
+		}
+		if i, ok := countryIdx[countryName]; ok {
+			data.Countries[i].Proxies = append(data.Countries[i].Proxies, n.name)
+		} else {
+			countryIdx[countryName] = len(data.Countries)
+			data.Countries = append(data.Countries, TemplateGroup{Name: countryName, Proxies: []string{n.name}})
+		}
+
+		if n.subName != "" {
+			if i, ok := subIdx[n.subName]; ok {
+				data.Subs[i].Proxies = append(data.Subs[i].Proxies, n.name)
+			} else {
+				subIdx[n.subName] = len(data.Subs)
+				data.Subs = append(data.Subs, TemplateGroup{Name: n.subName, Proxies: []string{n.name}})
+			}
+		}
+	}
+	exist := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		exist[n.name] = struct{}{}
+	}
+	for _, g := range groups {
+		proxies := make([]string, 0, len(g.Proxies))
+		for _, name := range g.Proxies {
+			if _, ok := exist[name]; ok {
+				proxies = append(proxies, name)
+			}
+		}
+		if len(proxies) > 0 {
+			data.Groups = append(data.Groups, TemplateGroup{Name: g.Name, Type: g.Type, Proxies: proxies})
+		}
+	}
+	sorted := slices.Clone(nodes)
+	slices.SortStableFunc(sorted, func(a, b shareNode) int {
+		return int(b.speedDown) - int(a.speedDown)
+	})
+	for _, n := range sorted {
+		data.bySpeed = append(data.bySpeed, n.name)
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+package subcer
+
+import (
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/google/uuid"
+)
+
+// callbacks 正在进行的 subconverter 请求的回调凭证
+var callbacks generic.MapOf[string, struct{}]
+
+// NewCallback 为一次 subconverter 请求生成回调凭证，请求结束后调用返回的函数使其失效
+func NewCallback() (string, func()) {
+	key := uuid.NewString()
+	callbacks.Store(key, struct{}{})
+	return key, func() {
+		callbacks.Delete(key)
+	}
+}
+
+// VerifyCallback 检查请求是否来自 subconverter 的回调
+func VerifyCallback(key string) bool {
+	if key == "" {
+		return false
+	}
+	_, ok := callbacks.Load(key)
+	return ok
+}
@@ -1,18 +1,73 @@
 package subcer
 
-const pref = `
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/bestruirui/bestsub/internal/config"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// Pref pref.yml 中可通过设置项修改的部分
+type Pref struct {
+	AddEmoji         bool
+	RemoveOldEmoji   bool
+	RuleBase         string
+	ExternalConfig   string
+	ClashHttpPort    int
+	ClashSocksPort   int
+	SingboxMixedPort int
+	AllowLan         bool
+	LogLevel         string
+	MaxThread        int
+	CacheConfig      int
+	CacheRuleset     int
+
+	Listen string
+	Port   int
+}
+
+// PrefKeys 影响 pref.yml 的设置项，修改后需要重新生成配置并重启 subconverter
+var PrefKeys = []string{
+	setting.SUBCONVERTER_ADD_EMOJI,
+	setting.SUBCONVERTER_REMOVE_OLD_EMOJI,
+	setting.SUBCONVERTER_RULE_BASE,
+	setting.SUBCONVERTER_EXTERNAL_CONFIG,
+	setting.SUBCONVERTER_CLASH_HTTP_PORT,
+	setting.SUBCONVERTER_CLASH_SOCKS_PORT,
+	setting.SUBCONVERTER_SINGBOX_MIXED_PORT,
+	setting.SUBCONVERTER_ALLOW_LAN,
+	setting.SUBCONVERTER_LOG_LEVEL,
+	setting.SUBCONVERTER_MAX_THREAD,
+	setting.SUBCONVERTER_CACHE_CONFIG,
+	setting.SUBCONVERTER_CACHE_RULESET,
+}
+
+var logLevels = []string{"verbose", "debug", "info", "warn", "error", "fatal"}
+
+var prefTmpl = template.Must(template.New("pref").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`
 common:
   api_mode: true
   base_path: base
-  clash_rule_base: base/all_base.tpl
-  surge_rule_base: base/all_base.tpl
-  surfboard_rule_base: base/all_base.tpl
-  mellow_rule_base: base/all_base.tpl
-  quan_rule_base: base/all_base.tpl
-  quanx_rule_base: base/all_base.tpl
-  loon_rule_base: base/all_base.tpl
-  sssub_rule_base: base/all_base.tpl
-  singbox_rule_base: base/all_base.tpl
+  clash_rule_base: {{quote .RuleBase}}
+  surge_rule_base: {{quote .RuleBase}}
+  surfboard_rule_base: {{quote .RuleBase}}
+  mellow_rule_base: {{quote .RuleBase}}
+  quan_rule_base: {{quote .RuleBase}}
+  quanx_rule_base: {{quote .RuleBase}}
+  loon_rule_base: {{quote .RuleBase}}
+  sssub_rule_base: {{quote .RuleBase}}
+  singbox_rule_base: {{quote .RuleBase}}
+  default_external_config: {{quote .ExternalConfig}}
   reload_conf_on_request: false
 
 
@@ -24,29 +79,202 @@ node_pref:
 
 
 emojis:
-  add_emoji: false
-  remove_old_emoji: false
+  add_emoji: {{.AddEmoji}}
+  remove_old_emoji: {{.RemoveOldEmoji}}
 
 template:
   globals:
-  - {key: clash.http_port, value: 7890}
-  - {key: clash.socks_port, value: 7891}
-  - {key: clash.allow_lan, value: true}
+  - {key: clash.http_port, value: {{.ClashHttpPort}}}
+  - {key: clash.socks_port, value: {{.ClashSocksPort}}}
+  - {key: clash.allow_lan, value: {{.AllowLan}}}
   - {key: clash.log_level, value: info}
   - {key: clash.external_controller, value: '127.0.0.1:9090'}
-  - {key: singbox.allow_lan, value: true}
-  - {key: singbox.mixed_port, value: 2080}
+  - {key: singbox.allow_lan, value: {{.AllowLan}}}
+  - {key: singbox.mixed_port, value: {{.SingboxMixedPort}}}
 
 server:
-  listen: %s
-  port: %d
+  listen: {{quote .Listen}}
+  port: {{.Port}}
 
 advanced:
-  log_level: debug
+  log_level: {{.LogLevel}}
   max_pending_connections: 10240
-  max_concurrent_threads: 100
+  max_concurrent_threads: {{.MaxThread}}
   enable_cache: true
   cache_subscription: 0
-  cache_config: 86400
-  cache_ruleset: 86400
-`
+  cache_config: {{.CacheConfig}}
+  cache_ruleset: {{.CacheRuleset}}
+`))
+
+// LoadPref 从设置项读取 pref 配置
+func LoadPref() Pref {
+	return loadPref(op.GetSettingStr)
+}
+
+// loadPref 未设置或无效的值使用默认值
+func loadPref(get func(key string) string) Pref {
+	values := make(map[string]string, len(PrefKeys))
+	for _, s := range setting.DefaultSetting() {
+		if slices.Contains(PrefKeys, s.Key) {
+			values[s.Key] = s.Value
+		}
+	}
+	for _, key := range PrefKeys {
+		if value := get(key); value != "" && ValidateSetting(key, value) == nil {
+			values[key] = value
+		}
+	}
+	atoi := func(key string) int {
+		i, _ := strconv.Atoi(values[key])
+		return i
+	}
+	return Pref{
+		AddEmoji:         values[setting.SUBCONVERTER_ADD_EMOJI] == "true",
+		RemoveOldEmoji:   values[setting.SUBCONVERTER_REMOVE_OLD_EMOJI] == "true",
+		RuleBase:         values[setting.SUBCONVERTER_RULE_BASE],
+		ExternalConfig:   values[setting.SUBCONVERTER_EXTERNAL_CONFIG],
+		ClashHttpPort:    atoi(setting.SUBCONVERTER_CLASH_HTTP_PORT),
+		ClashSocksPort:   atoi(setting.SUBCONVERTER_CLASH_SOCKS_PORT),
+		SingboxMixedPort: atoi(setting.SUBCONVERTER_SINGBOX_MIXED_PORT),
+		AllowLan:         values[setting.SUBCONVERTER_ALLOW_LAN] == "true",
+		LogLevel:         values[setting.SUBCONVERTER_LOG_LEVEL],
+		MaxThread:        atoi(setting.SUBCONVERTER_MAX_THREAD),
+		CacheConfig:      atoi(setting.SUBCONVERTER_CACHE_CONFIG),
+		CacheRuleset:     atoi(setting.SUBCONVERTER_CACHE_RULESET),
+		Listen:           config.Base().SubConverter.Host,
+		Port:             config.Base().SubConverter.Port,
+	}
+}
+
+// ValidateSetting 检查 pref 设置项的值，其他设置项直接返回 nil
+func ValidateSetting(key, value string) error {
+	if !slices.Contains(PrefKeys, key) {
+		return nil
+	}
+	switch key {
+	case setting.SUBCONVERTER_ADD_EMOJI, setting.SUBCONVERTER_REMOVE_OLD_EMOJI, setting.SUBCONVERTER_ALLOW_LAN:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s must be true or false", key)
+		}
+	case setting.SUBCONVERTER_CLASH_HTTP_PORT, setting.SUBCONVERTER_CLASH_SOCKS_PORT, setting.SUBCONVERTER_SINGBOX_MIXED_PORT:
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%s must be a port between 1 and 65535", key)
+		}
+	case setting.SUBCONVERTER_MAX_THREAD:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 1000 {
+			return fmt.Errorf("%s must be between 1 and 1000", key)
+		}
+	case setting.SUBCONVERTER_CACHE_CONFIG, setting.SUBCONVERTER_CACHE_RULESET:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s must be a non-negative number of seconds", key)
+		}
+	case setting.SUBCONVERTER_LOG_LEVEL:
+		if !slices.Contains(logLevels, value) {
+			return fmt.Errorf("%s must be one of %s", key, strings.Join(logLevels, ", "))
+		}
+	case setting.SUBCONVERTER_RULE_BASE:
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s is required", key)
+		}
+		return validateLocation(key, value)
+	case setting.SUBCONVERTER_EXTERNAL_CONFIG:
+		if value == "" {
+			return nil
+		}
+		return validateLocation(key, value)
+	}
+	return nil
+}
+
+// validateLocation 规则模板与外部配置可以是 http 链接或 subconverter 目录下的相对路径
+func validateLocation(key, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%s must be a single line", key)
+	}
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		if _, err := url.ParseRequestURI(value); err != nil {
+			return fmt.Errorf("%s is not a valid url: %w", key, err)
+		}
+		return nil
+	}
+	if filepath.IsAbs(value) || strings.HasPrefix(filepath.Clean(value), "..") {
+		return fmt.Errorf("%s must be a url or a path relative to the subconverter directory", key)
+	}
+	return nil
+}
+
+// ValidateSettings 检查待更新的 pref 设置项，与当前设置合并后检查端口冲突，返回 pref 是否有变化
+func ValidateSettings(items []setting.Setting) (bool, error) {
+	pending := make(map[string]string)
+	for _, item := range items {
+		if !slices.Contains(PrefKeys, item.Key) {
+			continue
+		}
+		if err := ValidateSetting(item.Key, item.Value); err != nil {
+			return false, err
+		}
+		if item.Value != op.GetSettingStr(item.Key) {
+			pending[item.Key] = item.Value
+		}
+	}
+	if len(pending) == 0 {
+		return false, nil
+	}
+	pref := loadPref(func(key string) string {
+		if value, ok := pending[key]; ok {
+			return value
+		}
+		return op.GetSettingStr(key)
+	})
+	return true, checkPorts(pref)
+}
+
+// checkPorts 客户端端口之间以及与 subconverter 服务端口不能相同
+func checkPorts(p Pref) error {
+	used := map[int]string{p.Port: "subconverter server port"}
+	for _, item := range []struct {
+		key  string
+		port int
+	}{
+		{setting.SUBCONVERTER_CLASH_HTTP_PORT, p.ClashHttpPort},
+		{setting.SUBCONVERTER_CLASH_SOCKS_PORT, p.ClashSocksPort},
+		{setting.SUBCONVERTER_SINGBOX_MIXED_PORT, p.SingboxMixedPort},
+	} {
+		if other, ok := used[item.port]; ok {
+			return fmt.Errorf("%s conflicts with %s", item.key, other)
+		}
+		used[item.port] = item.key
+	}
+	return nil
+}
+
+// WritePref 根据当前设置重新生成 pref.yml
+func WritePref() error {
+	var buf bytes.Buffer
+	if err := prefTmpl.Execute(&buf, LoadPref()); err != nil {
+		return fmt.Errorf("render subconverter config: %w", err)
+	}
+	if err := os.MkdirAll(config.Base().SubConverter.Path, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.Base().SubConverter.Path, "pref.yml"), buf.Bytes(), 0644)
+}
+
+// ApplyPref 重新生成 pref.yml 并重启 subconverter，外部 subconverter 的配置需要自行修改
+func ApplyPref() error {
+	if Remote() {
+		log.Infof("subconverter is remote, pref settings are not applied")
+		return nil
+	}
+	Lock()
+	defer Unlock()
+	Stop()
+	if err := Start(); err != nil {
+		return err
+	}
+	log.Info("subconverter config applied")
+	return nil
+}
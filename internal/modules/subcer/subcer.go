@@ -1,77 +1,261 @@
 package subcer
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bestruirui/bestsub/internal/config"
-	"github.com/bestruirui/bestsub/internal/utils"
+	"github.com/bestruirui/bestsub/internal/models/system"
 	"github.com/bestruirui/bestsub/internal/utils/log"
 )
 
 var (
-	cmd    *exec.Cmd
-	ctx    context.Context
-	cancel context.CancelFunc
-	mu     sync.RWMutex
+	mu sync.RWMutex
+
+	svMu     sync.Mutex
+	svCancel context.CancelFunc
+	svDone   chan struct{}
+	status   system.SubConverterStatus
 )
 
-func init() {
-	prefPath := filepath.Join(config.Base().SubConverter.Path, "pref.yml")
-	if _, err := os.Stat(prefPath); err != nil {
-		os.MkdirAll(config.Base().SubConverter.Path, 0755)
-		cfg := fmt.Sprintf(pref, config.Base().SubConverter.Host, config.Base().SubConverter.Port)
-		if err := os.WriteFile(prefPath, []byte(cfg), 0644); err != nil {
-			log.Errorf("failed to write subconverter config: %v", err)
-			os.Exit(1)
-		}
+const (
+	healthInterval = 15 * time.Second
+	healthTimeout  = 3 * time.Second
+	maxFailures    = 3
+	minBackoff     = time.Second
+	maxBackoff     = 5 * time.Minute
+	stableUptime   = time.Minute
+)
+
+// Start 根据当前设置生成 pref.yml 后启动 subconverter 并持续监控，进程退出或健康检查连续失败时按退避时间重启
+func Start() error {
+	svMu.Lock()
+	if svCancel != nil {
+		svMu.Unlock()
+		return nil
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	svCancel, svDone = cancel, make(chan struct{})
+	done := svDone
+	if Remote() {
+		status = system.SubConverterStatus{Remote: true, Url: GetBaseUrl()}
+		svMu.Unlock()
+		go watch(ctx, done)
+		log.Infof("using remote subconverter %s", GetBaseUrl())
+		return nil
+	}
+	if err := WritePref(); err != nil {
+		log.Warnf("failed to write subconverter config: %v", err)
+	}
+	svMu.Unlock()
+
+	cmd, err := launch(ctx)
+	go supervise(ctx, cmd, done)
+	if err != nil {
+		log.Warnf("failed to start subconverter process: %v", err)
+		return err
+	}
+	log.Info("subconverter service started")
+	return nil
 }
 
-func Start() error {
-	cfg := config.Base()
+// Stop 停止监控并结束 subconverter 进程
+func Stop() error {
+	svMu.Lock()
+	cancel, done := svCancel, svDone
+	svCancel, svDone = nil, nil
+	svMu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
 
-	ctx, cancel = context.WithCancel(context.Background())
-	binPath := filepath.Join(cfg.SubConverter.Path, "subconverter")
+	log.Debug("subconverter service stopped")
+	return nil
+}
+
+// Status subconverter 当前状态
+func Status() system.SubConverterStatus {
+	svMu.Lock()
+	defer svMu.Unlock()
+	return status
+}
+
+func launch(ctx context.Context) (*exec.Cmd, error) {
+	binPath := filepath.Join(config.Base().SubConverter.Path, "subconverter")
 	if runtime.GOOS == "windows" {
 		binPath += ".exe"
 	}
-
-	cmd = exec.CommandContext(ctx, binPath)
+	cmd := exec.CommandContext(ctx, binPath)
 	cmd.Dir = filepath.Dir(binPath)
-	if utils.IsDebug() {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	}
+	cmd.Stdout = &logWriter{}
+	cmd.Stderr = &logWriter{}
 	if err := cmd.Start(); err != nil {
-		cancel()
-		log.Warnf("failed to start subconverter process: %v", err)
-		return err
+		updateStatus(func(s *system.SubConverterStatus) {
+			s.Running, s.Healthy, s.Pid = false, false, 0
+			s.LastError = err.Error()
+		})
+		return nil, err
 	}
+	updateStatus(func(s *system.SubConverterStatus) {
+		s.Running, s.Healthy, s.Pid = true, false, cmd.Process.Pid
+		s.StartedAt = time.Now().Format(time.RFC3339)
+	})
+	return cmd, nil
+}
 
-	log.Info("subconverter service started")
-	return nil
+func supervise(ctx context.Context, cmd *exec.Cmd, done chan struct{}) {
+	defer close(done)
+	backoff := minBackoff
+	for {
+		if cmd != nil {
+			startedAt := time.Now()
+			monitor(ctx, cmd)
+			updateStatus(func(s *system.SubConverterStatus) {
+				s.Running, s.Healthy, s.Pid = false, false, 0
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(startedAt) > stableUptime {
+				backoff = minBackoff
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("subconverter is down, restarting in %s", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+		var err error
+		if cmd, err = launch(ctx); err != nil {
+			log.Warnf("failed to restart subconverter process: %v", err)
+			continue
+		}
+		updateStatus(func(s *system.SubConverterStatus) { s.Restarts++ })
+		log.Infof("subconverter restarted, pid: %d", cmd.Process.Pid)
+	}
 }
 
-func Stop() error {
-	if cancel != nil {
-		cancel()
+// monitor 等待进程退出，健康检查连续失败时结束进程
+func monitor(ctx context.Context, cmd *exec.Cmd) {
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			<-exited
+			return
+		case err := <-exited:
+			if err == nil {
+				err = fmt.Errorf("exited")
+			}
+			log.Warnf("subconverter process exited: %v", err)
+			updateStatus(func(s *system.SubConverterStatus) { s.LastError = err.Error() })
+			return
+		case <-ticker.C:
+			err := check()
+			if err == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			log.Debugf("subconverter health check failed (%d/%d): %v", failures, maxFailures, err)
+			if failures >= maxFailures {
+				log.Warnf("subconverter health check failed %d times, killing process", failures)
+				cmd.Process.Kill()
+				<-exited
+				return
+			}
+		}
 	}
+}
 
-	if cmd != nil && cmd.Process != nil {
-		cmd.Wait()
+// watch 外部 subconverter 不由 BestSub 管理，只定期检查是否可用
+func watch(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		err := check()
+		updateStatus(func(s *system.SubConverterStatus) { s.Running = err == nil })
+		if err != nil {
+			log.Debugf("remote subconverter health check failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
+}
 
-	log.Debug("subconverter service stopped")
-	return nil
+// check 请求 /version 检查 subconverter 是否可用并记录结果
+func check() error {
+	version, err := fetchVersion()
+	updateStatus(func(s *system.SubConverterStatus) {
+		s.LastCheck = time.Now().Format(time.RFC3339)
+		s.Healthy = err == nil
+		if err == nil {
+			s.Version = version
+		} else {
+			s.LastError = err.Error()
+		}
+	})
+	return err
+}
+
+func updateStatus(fn func(s *system.SubConverterStatus)) {
+	svMu.Lock()
+	defer svMu.Unlock()
+	fn(&status)
+}
+
+// logWriter 将 subconverter 的输出按行写入日志
+type logWriter struct {
+	buf []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSpace(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(line, "[ERROR]"), strings.Contains(line, "[FATAL]"), strings.Contains(line, "[WARN]"):
+			log.Warnf("[subconverter] %s", line)
+		default:
+			log.Debugf("[subconverter] %s", line)
+		}
+	}
+	if len(w.buf) > 64*1024 {
+		w.buf = w.buf[:0]
+	}
+	return len(p), nil
 }
 
 func Lock() {
@@ -89,22 +273,49 @@ func Unlock() {
 	mu.Unlock()
 }
 
+// Remote 是否使用外部 subconverter
+func Remote() bool {
+	return config.Base().SubConverter.Url != ""
+}
+
 func GetBaseUrl() string {
+	if Remote() {
+		return config.Base().SubConverter.Url
+	}
 	return fmt.Sprintf("http://127.0.0.1:%d", config.Base().SubConverter.Port)
 }
+
+// CallbackUrl subconverter 访问 BestSub 接口时使用的地址
+func CallbackUrl() string {
+	if callback := config.Base().SubConverter.Callback; callback != "" {
+		return callback
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d", config.Base().Server.Port)
+}
+
+var versionClient = &http.Client{Timeout: healthTimeout}
+
 func GetVersion() string {
-	resp, err := http.Get(fmt.Sprintf("%s/version", GetBaseUrl()))
+	version, _ := fetchVersion()
+	return version
+}
+
+func fetchVersion() (string, error) {
+	resp, err := versionClient.Get(fmt.Sprintf("%s/version", GetBaseUrl()))
 	if err != nil {
-		return ""
+		return "", err
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return ""
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
-	parts := strings.Split(string(body), " ")
+	parts := strings.Split(strings.TrimSpace(string(body)), " ")
 	if len(parts) > 1 {
-		return parts[1]
+		return parts[1], nil
 	}
-	return ""
+	return "", nil
 }
@@ -0,0 +1,32 @@
+package subio
+
+import (
+	"encoding/json"
+	"time"
+
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+)
+
+// ExportVersion 导出文件格式版本
+const ExportVersion = 1
+
+// Export 将订阅转换为可在实例间迁移的导出文件
+func Export(subs []subModel.Data) subModel.Export {
+	export := subModel.Export{
+		Version:    ExportVersion,
+		ExportedAt: time.Now(),
+		Subs:       make([]subModel.Request, 0, len(subs)),
+	}
+	for _, s := range subs {
+		req := subModel.Request{
+			Name:     s.Name,
+			Enable:   s.Enable,
+			CronExpr: s.CronExpr,
+			Tags:     make([]string, 0),
+		}
+		json.Unmarshal([]byte(s.Tags), &req.Tags)
+		json.Unmarshal([]byte(s.Config), &req.Config)
+		export.Subs = append(export.Subs, req)
+	}
+	return export
+}
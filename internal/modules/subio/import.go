@@ -0,0 +1,230 @@
+package subio
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatAuto    = "auto"
+	FormatText    = "text"
+	FormatOPML    = "opml"
+	FormatClash   = "clash"
+	FormatBestSub = "bestsub"
+)
+
+// Defaults 导入时为缺少字段的订阅补全的默认值
+type Defaults struct {
+	CronExpr string
+	Tags     []string
+	Enable   bool
+	Timeout  int
+}
+
+// Parse 解析导入内容，返回待创建的订阅请求
+func Parse(content []byte, format string, defaults Defaults) ([]subModel.Request, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, errors.New("empty content")
+	}
+	if format == "" || format == FormatAuto {
+		format = Detect(content)
+	}
+	var (
+		reqs []subModel.Request
+		err  error
+	)
+	switch format {
+	case FormatBestSub:
+		reqs, err = parseBestSub(content)
+	case FormatOPML:
+		reqs, err = parseOPML(content)
+	case FormatClash:
+		reqs, err = parseClash(content)
+	case FormatText:
+		reqs = parseText(content)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range reqs {
+		fillDefaults(&reqs[i], defaults)
+	}
+	return reqs, nil
+}
+
+// Detect 根据内容推断导入格式
+func Detect(content []byte) string {
+	switch {
+	case content[0] == '{' || content[0] == '[':
+		return FormatBestSub
+	case content[0] == '<':
+		return FormatOPML
+	case bytes.Contains(content, []byte("proxy-providers:")):
+		return FormatClash
+	default:
+		return FormatText
+	}
+}
+
+func fillDefaults(req *subModel.Request, defaults Defaults) {
+	if req.CronExpr == "" {
+		req.CronExpr = defaults.CronExpr
+		req.Enable = defaults.Enable
+	}
+	if len(req.Tags) == 0 && len(defaults.Tags) > 0 {
+		req.Tags = defaults.Tags
+	}
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+	if req.Config.Timeout == 0 {
+		req.Config.Timeout = defaults.Timeout
+	}
+	if req.Name == "" {
+		req.Name = nameFromUrl(req.Config.Url)
+	}
+}
+
+func parseBestSub(content []byte) ([]subModel.Request, error) {
+	if content[0] == '[' {
+		var reqs []subModel.Request
+		if err := json.Unmarshal(content, &reqs); err != nil {
+			return nil, err
+		}
+		return reqs, nil
+	}
+	var export subModel.Export
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, err
+	}
+	return export.Subs, nil
+}
+
+// parseText 每行一个订阅链接，#开头为注释，链接的 fragment 作为订阅名称
+func parseText(content []byte) []subModel.Request {
+	var reqs []subModel.Request
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !isHttpUrl(line) {
+			continue
+		}
+		req := subModel.Request{}
+		if u, err := url.Parse(line); err == nil && u.Fragment != "" {
+			req.Name = u.Fragment
+			u.Fragment = ""
+			line = u.String()
+		}
+		req.Config.Url = line
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// parseOPML 读取任意带有 xmlUrl/url 属性的 outline 元素，父级 outline 的名称作为标签
+func parseOPML(content []byte) ([]subModel.Request, error) {
+	var reqs []subModel.Request
+	var groups []string
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string, len(t.Attr))
+			for _, attr := range t.Attr {
+				attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			link := attrs["xmlurl"]
+			if link == "" {
+				link = attrs["url"]
+			}
+			name := attrs["title"]
+			if name == "" {
+				name = attrs["text"]
+			}
+			if link == "" || !isHttpUrl(link) {
+				groups = append(groups, name)
+				continue
+			}
+			groups = append(groups, "")
+			req := subModel.Request{Name: name}
+			req.Config.Url = link
+			for _, g := range groups {
+				if g != "" {
+					req.Tags = append(req.Tags, g)
+				}
+			}
+			reqs = append(reqs, req)
+		case xml.EndElement:
+			if len(groups) > 0 {
+				groups = groups[:len(groups)-1]
+			}
+		}
+	}
+	return reqs, nil
+}
+
+type clashConfig struct {
+	ProxyProviders map[string]struct {
+		Type     string `yaml:"type"`
+		Url      string `yaml:"url"`
+		Interval int    `yaml:"interval"`
+	} `yaml:"proxy-providers"`
+}
+
+// parseClash 读取 Clash/Mihomo 配置中的 http 类型 proxy-providers
+func parseClash(content []byte) ([]subModel.Request, error) {
+	var cfg clashConfig
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(cfg.ProxyProviders))
+	for name := range cfg.ProxyProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var reqs []subModel.Request
+	for _, name := range names {
+		provider := cfg.ProxyProviders[name]
+		if provider.Type != "http" || !isHttpUrl(provider.Url) {
+			continue
+		}
+		req := subModel.Request{Name: name}
+		req.Config.Url = provider.Url
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+func isHttpUrl(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func nameFromUrl(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return s
+	}
+	return u.Host
+}
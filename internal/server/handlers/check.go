@@ -85,6 +85,10 @@ func createCheck(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	if err := req.Task.Validate(); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	checkData := req.GenData()
 	if err := op.CreateCheck(c.Request.Context(), &checkData); err != nil {
 		log.Errorf("failed to create check: %v", err)
@@ -168,6 +172,10 @@ func updateCheck(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	if err := req.Task.Validate(); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	checkData := req.GenData()
 	checkData.ID = uint16(id)
 	if err := op.UpdateCheck(c.Request.Context(), &checkData); err != nil {
@@ -46,7 +46,7 @@ func init() {
 
 // healthCheck 健康检查
 // @Summary 健康检查
-// @Description 检查服务健康状态，包括数据库连接状态
+// @Description 检查服务健康状态，包括数据库连接状态与 subconverter 状态，subconverter 不可用时状态为 degraded
 // @Tags 系统
 // @Accept json
 // @Produce json
@@ -69,10 +69,14 @@ func healthCheck(c *gin.Context) {
 	}
 
 	response := system.HealthResponse{
-		Status:    "ok",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Version:   info.Version,
-		Database:  opStatus,
+		Status:       "ok",
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Version:      info.Version,
+		Database:     opStatus,
+		SubConverter: subcer.Status(),
+	}
+	if !response.SubConverter.Running {
+		response.Status = "degraded"
 	}
 
 	// 如果数据库连接失败，返回503状态码
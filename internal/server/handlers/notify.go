@@ -52,6 +52,10 @@ func init() {
 			router.NewRoute("/test", router.POST).
 				Handle(testNotify),
 		).
+		AddRoute(
+			router.NewRoute("/type", router.GET).
+				Handle(getNotifyTypes),
+		).
 		AddRoute(
 			router.NewRoute("/template", router.GET).
 				Handle(getTemplates),
@@ -98,6 +102,20 @@ func getNotifyChannelConfig(c *gin.Context) {
 	}
 }
 
+// getNotifyTypes 获取通知类型
+// @Summary 获取通知类型
+// @Description 返回通知类型及其在 notify_operation 设置中对应的位
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=[]notifyModel.TypeInfo} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/notify/type [get]
+func getNotifyTypes(c *gin.Context) {
+	resp.Success(c, notifyModel.Types())
+}
+
 // getNotifyList 获取通知
 // @Summary 获取通知
 // @Tags 通知
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bestruirui/bestsub/internal/database/op"
 	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/modules/subcer"
 	"github.com/bestruirui/bestsub/internal/server/middleware"
 	"github.com/bestruirui/bestsub/internal/server/resp"
 	"github.com/bestruirui/bestsub/internal/server/router"
@@ -51,7 +52,7 @@ func getSetting(c *gin.Context) {
 
 // updateSetting 更新配置项
 // @Summary 更新配置项
-// @Description 根据请求数据中的ID批量更新配置项的值和描述
+// @Description 根据请求数据中的ID批量更新配置项的值和描述，subconverter 相关配置修改后会重新生成 pref.yml 并重启 subconverter
 // @Tags 配置
 // @Accept json
 // @Produce json
@@ -68,13 +69,25 @@ func updateSetting(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	prefChanged, err := subcer.ValidateSettings(req)
+	if err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	err := op.UpdateSetting(context.Background(), &req)
+	err = op.UpdateSetting(context.Background(), &req)
 	if err != nil {
 		log.Errorf("Failed to update config: %v", err)
 		resp.Error(c, http.StatusInternalServerError, "failed to update config")
 		return
 	}
+	if prefChanged {
+		if err := subcer.ApplyPref(); err != nil {
+			log.Errorf("Failed to apply subconverter config: %v", err)
+			resp.Error(c, http.StatusInternalServerError, "config saved but failed to restart subconverter")
+			return
+		}
+	}
 
 	resp.Success(c, nil)
 }
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
 	shareModel "github.com/bestruirui/bestsub/internal/models/share"
 	"github.com/bestruirui/bestsub/internal/modules/share"
+	"github.com/bestruirui/bestsub/internal/modules/share/encoder"
+	"github.com/bestruirui/bestsub/internal/modules/subcer"
 	"github.com/bestruirui/bestsub/internal/server/middleware"
 	"github.com/bestruirui/bestsub/internal/server/resp"
 	"github.com/bestruirui/bestsub/internal/server/router"
@@ -26,6 +32,10 @@ func init() {
 			router.NewRoute("", router.GET).
 				Handle(getShare),
 		).
+		AddRoute(
+			router.NewRoute("/preview", router.POST).
+				Handle(previewShare),
+		).
 		AddRoute(
 			router.NewRoute("/:id", router.PUT).
 				Handle(updateShare),
@@ -33,6 +43,22 @@ func init() {
 		AddRoute(
 			router.NewRoute("/:id", router.DELETE).
 				Handle(deleteShare),
+		).
+		AddRoute(
+			router.NewRoute("/:id/rotate", router.POST).
+				Handle(rotateShareToken),
+		).
+		AddRoute(
+			router.NewRoute("/:id/sign", router.POST).
+				Handle(signShare),
+		).
+		AddRoute(
+			router.NewRoute("/:id/access", router.GET).
+				Handle(getShareAccessLog),
+		).
+		AddRoute(
+			router.NewRoute("/:id/clients", router.GET).
+				Handle(getShareAccessStats),
 		)
 	router.NewGroupRouter("/api/v1/share").
 		AddRoute(
@@ -53,6 +79,7 @@ func init() {
 // @Security BearerAuth
 // @Param data body shareModel.Request true "分享数据"
 // @Success 200 {object} resp.ResponseStruct{data=shareModel.Response} "创建成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
 // @Failure 401 {object} resp.ResponseStruct "未授权"
 // @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
 // @Router /api/v1/share [post]
@@ -63,6 +90,10 @@ func createShare(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	if err := validateShareRequest(c.Request.Context(), &req); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	data := req.GenData()
 	if err := op.CreateShare(c.Request.Context(), &data); err != nil {
 		resp.Error(c, http.StatusInternalServerError, err.Error())
@@ -103,6 +134,7 @@ func getShare(c *gin.Context) {
 // @Param id path string true "分享ID"
 // @Param data body shareModel.Request true "分享数据"
 // @Success 200 {object} resp.ResponseStruct{data=shareModel.Response} "更新成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
 // @Failure 401 {object} resp.ResponseStruct "未授权"
 // @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
 // @Router /api/v1/share/{id} [put]
@@ -112,6 +144,10 @@ func updateShare(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	if err := validateShareRequest(c.Request.Context(), &req); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	id := c.Param("id")
 	idUint, err := strconv.ParseUint(id, 10, 16)
 	if err != nil {
@@ -124,6 +160,8 @@ func updateShare(c *gin.Context) {
 		resp.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	share.ResetAccess(data.ID)
+	share.InvalidateCache(data.ID)
 	resp.Success(c, data.GenResponse())
 }
 
@@ -149,46 +187,67 @@ func deleteShare(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	share.ResetAccess(uint16(idUint))
+	share.InvalidateCache(uint16(idUint))
 	resp.Success(c, nil)
 }
 
-// @Summary 获取订阅内容 纯Mihomo格式的节点
-// @Description 获取订阅内容 纯Mihomo格式的节点
+// @Summary 获取订阅内容 仅节点
+// @Description 获取订阅内容 仅节点，通过 target 指定格式(clash/singbox/v2ray/surge/quanx/loon)，未指定时根据 User-Agent 识别，默认Mihomo格式
 // @Tags 分享
 // @Accept json
 // @Produce plain
-// @Param token path string true "分享token"
+// @Param token path string true "分享token或签名token"
+// @Param If-None-Match header string false "上次响应的ETag"
+// @Param target query string false "输出格式"
 // @Success 200 {string} string "获取成功，内容为yaml/plain格式"
+// @Header 200 {string} Subscription-Userinfo "upload=0; download=0; total=0; expire=0"
+// @Header 200 {string} Profile-Update-Interval "客户端更新间隔(小时)"
+// @Header 200 {string} Content-Disposition "配置名称"
+// @Header 200 {string} ETag "内容标识"
+// @Success 304 {string} string "内容未变化"
+// @Failure 403 {object} resp.ResponseStruct "访问被拒绝或签名无效"
+// @Failure 429 {object} resp.ResponseStruct "访问过于频繁"
 // @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
 // @Router /api/v1/share/node/{token} [get]
 func getShareNodeContent(c *gin.Context) {
 	token := c.Param("token")
-	clientIp := c.ClientIP()
-	if token == "" {
-		resp.Error(c, http.StatusInternalServerError, "token is required")
+	callback := subcer.VerifyCallback(c.Query("callback"))
+	shareData, signature, ok := loadShare(c, token)
+	if !ok {
 		return
 	}
-	shareData, err := op.GetShareByToken(c.Request.Context(), token)
-	if err != nil {
-		resp.Error(c, http.StatusInternalServerError, err.Error())
+	if !callback && !checkShareAccess(c, shareData) {
 		return
 	}
-	if !shareData.Enable {
-		resp.Error(c, http.StatusInternalServerError, "share not enable")
-		return
+	var enc encoder.Encoder
+	if target := c.Query("target"); target != "" {
+		var ok bool
+		if enc, ok = encoder.Get(target); !ok {
+			resp.Error(c, http.StatusBadRequest, "unsupported target, available: "+strings.Join(encoder.Targets(), ","))
+			return
+		}
+	} else if detected, ok := encoder.Detect(c.GetHeader("User-Agent")); ok {
+		enc = detected
+	} else {
+		enc, _ = encoder.Get("clash")
 	}
-	if shareData.Expires < uint64(time.Now().Unix()) && shareData.Expires > 0 {
-		resp.Error(c, http.StatusInternalServerError, "share expired")
-		return
+	result, etag := share.Cached(shareData, "node", enc.Name()+"\n"+signature, func() share.Result {
+		return share.GenNodeData(shareData.Gen, enc)
+	})
+	notModified := c.GetHeader("If-None-Match") == etag
+	if !callback {
+		op.UpdateShareAccessCount(c.Request.Context(), shareData.ID)
+		shareData.AccessCount++
+		recordShareAccess(c, shareData.ID, result, notModified)
 	}
-	if shareData.MaxAccessCount > 0 && shareData.MaxAccessCount <= shareData.AccessCount {
-		resp.Error(c, http.StatusInternalServerError, "share access count exceeded")
+	setProfileHeaders(c, shareData)
+	c.Header("ETag", etag)
+	if notModified {
+		c.Status(http.StatusNotModified)
 		return
 	}
-	if clientIp != "127.0.0.1" {
-		op.UpdateShareAccessCount(c.Request.Context(), shareData.ID)
-	}
-	c.Data(http.StatusOK, "text/plain; charset=utf-8", share.GenNodeData(shareData.Gen))
+	c.Data(http.StatusOK, enc.ContentType(), result.Data)
 }
 
 // @Summary 获取订阅内容 带规则的订阅
@@ -196,33 +255,316 @@ func getShareNodeContent(c *gin.Context) {
 // @Tags 分享
 // @Accept json
 // @Produce plain
-// @Param token path string true "分享token"
+// @Param token path string true "分享token或签名token"
+// @Param If-None-Match header string false "上次响应的ETag"
 // @Success 200 {string} string "获取成功，内容为yaml/plain格式"
+// @Header 200 {string} Subscription-Userinfo "upload=0; download=0; total=0; expire=0"
+// @Header 200 {string} Profile-Update-Interval "客户端更新间隔(小时)"
+// @Header 200 {string} Content-Disposition "配置名称"
+// @Header 200 {string} ETag "内容标识"
+// @Success 304 {string} string "内容未变化"
+// @Failure 403 {object} resp.ResponseStruct "访问被拒绝或签名无效"
+// @Failure 429 {object} resp.ResponseStruct "访问过于频繁"
 // @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
 // @Router /api/v1/share/sub/{token} [get]
 func getShareSubContent(c *gin.Context) {
 	token := c.Param("token")
-	if token == "" {
-		resp.Error(c, http.StatusInternalServerError, "token is required")
+	shareData, signature, ok := loadShare(c, token)
+	if !ok {
 		return
 	}
-	shareData, err := op.GetShareByToken(c.Request.Context(), token)
+	if !checkShareAccess(c, shareData) {
+		return
+	}
+	op.UpdateShareAccessCount(c.Request.Context(), shareData.ID)
+	shareData.AccessCount++
+	userAgent, rawQuery := c.GetHeader("User-Agent"), share.SubQuery(c.Request.URL.RawQuery)
+	nodeToken := shareData.Token
+	if signature != "" {
+		nodeToken = token
+	}
+	variant := share.SubVariant(shareData.Gen, userAgent, rawQuery)
+	result, etag := share.Cached(shareData, "sub", variant+"\n"+signature, func() share.Result {
+		return share.GenSubData(shareData.Gen, userAgent, nodeToken, rawQuery)
+	})
+	notModified := c.GetHeader("If-None-Match") == etag
+	recordShareAccess(c, shareData.ID, result, notModified)
+	setProfileHeaders(c, shareData)
+	c.Header("ETag", etag)
+	if notModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", result.Data)
+}
+
+// @Summary 更换分享token
+// @Description 立即更换分享token，旧token在宽限期内仍然有效，同时清空已记录的客户端
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享ID"
+// @Param grace query int false "旧token宽限时间(分钟)，默认使用分享的访问控制配置"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.Response} "更换成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/share/{id}/rotate [post]
+func rotateShareToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	shareData, err := op.GetShareByID(c.Request.Context(), uint16(id))
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	grace := uint64(shareData.AccessConfig().RotateGrace)
+	if g := c.Query("grace"); g != "" {
+		if grace, err = strconv.ParseUint(g, 10, 32); err != nil {
+			resp.ErrorBadRequest(c)
+			return
+		}
+	}
+	shareData, err = op.RotateShareToken(c.Request.Context(), uint16(id), time.Duration(grace)*time.Minute)
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	share.ResetAccess(shareData.ID)
+	resp.Success(c, shareData.GenResponse())
+}
+
+// @Summary 预览分享内容
+// @Description 按生成配置预览匹配的节点、重命名结果和分组统计，不会创建分享也不计入访问次数
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param target query string false "同时渲染的输出格式，配置了规则模板时使用模板渲染"
+// @Param data body shareModel.GenConfig true "生成配置"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.Preview} "预览成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/share/preview [post]
+func previewShare(c *gin.Context) {
+	var genConfig shareModel.GenConfig
+	if err := c.ShouldBindJSON(&genConfig); err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	resp.Success(c, share.Preview(genConfig, c.Query("target")))
+}
+
+// @Summary 生成签名分享链接
+// @Description 基于已有分享生成带过期时间的签名链接，可附加过滤条件与数量限制，链接不保存到数据库，更换分享token后全部失效
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享ID"
+// @Param data body shareModel.SignRequest true "签名参数"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.SignResponse} "生成成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/share/{id}/sign [post]
+func signShare(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	var req shareModel.SignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if req.Filter != nil {
+		if err := node.ValidateFilter(*req.Filter); err != nil {
+			resp.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	shareData, err := op.GetShareByID(c.Request.Context(), uint16(id))
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	token, claims := share.Sign(shareData, req)
+	resp.Success(c, shareModel.SignResponse{
+		Token:   token,
+		Expires: claims.Expires,
+		NodeUrl: "/api/v1/share/node/" + token,
+		SubUrl:  "/api/v1/share/sub/" + token,
+	})
+}
+
+// @Summary 获取分享访问记录
+// @Description 获取分享链接最近的访问记录，按时间倒序
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享ID"
+// @Param limit query int false "返回数量" default(100)
+// @Success 200 {object} resp.ResponseStruct{data=[]shareModel.AccessLog} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/share/{id}/access [get]
+func getShareAccessLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	logs, err := op.GetShareAccessLog(c.Request.Context(), uint16(id), limit)
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, logs)
+}
+
+// @Summary 获取分享访问客户端统计
+// @Description 按客户端IP统计分享链接的访问记录，不同IP数量异常时链接可能已泄露
+// @Tags 分享
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分享ID"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.AccessStats} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/share/{id}/clients [get]
+func getShareAccessStats(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	stats, err := op.GetShareAccessStats(c.Request.Context(), uint16(id))
 	if err != nil {
 		resp.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	resp.Success(c, stats)
+}
+
+// loadShare 根据 token 获取可访问的分享，不可用时写入错误响应
+//
+// 签名 token 返回附加了过滤条件的分享副本，signature 用于区分缓存，普通 token 为空
+func loadShare(c *gin.Context, token string) (shareData *shareModel.Data, signature string, ok bool) {
+	if token == "" {
+		resp.Error(c, http.StatusInternalServerError, "token is required")
+		return nil, "", false
+	}
+	shareData, err := op.GetShareByToken(c.Request.Context(), token)
+	if err != nil {
+		if !strings.Contains(token, ".") {
+			resp.Error(c, http.StatusInternalServerError, err.Error())
+			return nil, "", false
+		}
+		claims, signErr := share.ParseSigned(token)
+		if signErr != nil {
+			resp.Error(c, http.StatusForbidden, signErr.Error())
+			return nil, "", false
+		}
+		if shareData, err = op.GetShareByID(c.Request.Context(), claims.ShareID); err != nil {
+			resp.Error(c, http.StatusInternalServerError, err.Error())
+			return nil, "", false
+		}
+		if shareData, err = share.ApplySigned(shareData, token, claims); err != nil {
+			resp.Error(c, http.StatusForbidden, err.Error())
+			return nil, "", false
+		}
+		signature = token[strings.LastIndexByte(token, '.')+1:]
+	}
 	if !shareData.Enable {
 		resp.Error(c, http.StatusInternalServerError, "share not enable")
-		return
+		return nil, "", false
 	}
 	if shareData.Expires < uint64(time.Now().Unix()) && shareData.Expires > 0 {
 		resp.Error(c, http.StatusInternalServerError, "share expired")
-		return
+		return nil, "", false
 	}
 	if shareData.MaxAccessCount > 0 && shareData.MaxAccessCount <= shareData.AccessCount {
 		resp.Error(c, http.StatusInternalServerError, "share access count exceeded")
-		return
+		return nil, "", false
 	}
-	op.UpdateShareAccessCount(c.Request.Context(), shareData.ID)
-	c.Data(http.StatusOK, "text/plain; charset=utf-8", share.GenSubData(shareData.Gen, c.GetHeader("User-Agent"), token, c.Request.URL.RawQuery))
+	return shareData, signature, true
+}
+
+// checkShareAccess 检查分享访问控制，不通过时写入错误响应
+func checkShareAccess(c *gin.Context, shareData *shareModel.Data) bool {
+	err := share.CheckAccess(c.Request.Context(), shareData, c.ClientIP(), c.GetHeader("User-Agent"))
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, share.ErrRateLimited):
+		resp.Error(c, http.StatusTooManyRequests, err.Error())
+	default:
+		resp.Error(c, http.StatusForbidden, err.Error())
+	}
+	return false
+}
+
+func setProfileHeaders(c *gin.Context, shareData *shareModel.Data) {
+	for k, v := range share.ProfileHeaders(shareData) {
+		c.Header(k, v)
+	}
+}
+
+func recordShareAccess(c *gin.Context, shareID uint16, result share.Result, notModified bool) {
+	size := len(result.Data)
+	if notModified {
+		size = 0
+	}
+	op.RecordShareAccess(shareModel.AccessLog{
+		ShareID:    shareID,
+		AccessedAt: time.Now().Unix(),
+		IP:         c.ClientIP(),
+		UserAgent:  c.GetHeader("User-Agent"),
+		Format:     result.Format,
+		NodeCount:  uint32(result.NodeCount),
+		Bytes:      uint32(size),
+	})
+}
+
+// validateShareRequest 检查分享配置中的过滤、重命名、排序、分组、模板与访问控制条件
+func validateShareRequest(ctx context.Context, req *shareModel.Request) error {
+	if err := node.ValidateFilter(req.Gen.Filter); err != nil {
+		return err
+	}
+	if err := share.ValidateRename(req.Gen.Rename); err != nil {
+		return err
+	}
+	if err := share.ValidateGroups(req.Gen.Groups); err != nil {
+		return err
+	}
+	if req.Gen.Narrow != nil {
+		if err := node.ValidateFilter(*req.Gen.Narrow); err != nil {
+			return err
+		}
+	}
+	if err := req.Gen.Order.Validate(); err != nil {
+		return err
+	}
+	if o := req.Gen.Order; len(req.Gen.Groups) > 0 && (len(o.Sort) > 0 || o.Reverse || o.GroupBy != "" || o.GroupLimit > 0) {
+		return errors.New("share order only supports limit when groups are set, use the order of each group")
+	}
+	if err := share.ValidateTemplateConfig(ctx, req.Gen.Template); err != nil {
+		return err
+	}
+	return req.Access.Validate()
 }
@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bestruirui/bestsub/internal/core/cron"
+	"github.com/bestruirui/bestsub/internal/core/fetch"
 	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
 	"github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/modules/subio"
 	"github.com/bestruirui/bestsub/internal/server/middleware"
 	"github.com/bestruirui/bestsub/internal/server/resp"
 	"github.com/bestruirui/bestsub/internal/server/router"
@@ -41,6 +48,27 @@ func init() {
 		AddRoute(
 			router.NewRoute("/batch", router.POST).
 				Handle(batchCreateSub),
+		).
+		AddRoute(
+			router.NewRoute("/:id/history", router.GET).
+				Handle(getSubHistory),
+		).
+		AddRoute(
+			router.NewRoute("/:id/quality", router.GET).
+				Handle(getSubQuality),
+		).
+		AddRoute(
+			router.NewRoute("/export", router.GET).
+				Handle(exportSub),
+		).
+		AddRoute(
+			router.NewRoute("/import", router.POST).
+				Handle(importSub),
+		)
+	router.NewGroupRouter("/api/v1/sub").
+		AddRoute(
+			router.NewRoute("/raw/:token", router.GET).
+				Handle(getSubRawContent),
 		)
 }
 
@@ -63,6 +91,10 @@ func createSub(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	if err := req.Config.Validate(); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	subData := req.GenData(0)
 	if err := op.CreateSub(c.Request.Context(), &subData); err != nil {
 		log.Errorf("failed to create sub: %v", err)
@@ -143,6 +175,10 @@ func updateSub(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	if err := req.Config.Validate(); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	subData := req.GenData(uint16(id))
 	if err := op.UpdateSub(c.Request.Context(), &subData); err != nil {
 		log.Errorf("failed to update sub: %v", err)
@@ -242,6 +278,10 @@ func batchCreateSub(c *gin.Context) {
 
 	subs := make([]*sub.Data, len(reqs))
 	for i, req := range reqs {
+		if err := req.Config.Validate(); err != nil {
+			resp.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		subData := req.GenData(0)
 		subs[i] = &subData
 	}
@@ -262,3 +302,196 @@ func batchCreateSub(c *gin.Context) {
 	}
 	resp.Success(c, respData)
 }
+
+// getSubHistory 获取订阅获取历史
+// @Summary 获取订阅获取历史
+// @Description 获取订阅每次运行的记录以及与上一次相比新增/消失的节点
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅链接ID"
+// @Param limit query int false "返回条数，默认20"
+// @Success 200 {object} resp.ResponseStruct{data=[]sub.HistoryResponse} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/sub/{id}/history [get]
+func getSubHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			resp.ErrorBadRequest(c)
+			return
+		}
+	}
+	histories, err := op.GetSubHistory(c.Request.Context(), uint16(id), limit)
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respData := make([]sub.HistoryResponse, len(histories))
+	for i := range histories {
+		respData[i] = histories[i].GenResponse()
+	}
+	resp.Success(c, respData)
+}
+
+// getSubQuality 获取订阅质量报告
+// @Summary 获取订阅质量报告
+// @Description 根据获取历史与节点池数据计算订阅的入池率、存活率、平均速度、贡献占比与综合评分
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅链接ID"
+// @Success 200 {object} resp.ResponseStruct{data=sub.Quality} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/sub/{id}/quality [get]
+func getSubQuality(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	quality, err := fetch.Quality(c.Request.Context(), uint16(id))
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, quality)
+}
+
+// exportSub 导出订阅
+// @Summary 导出订阅
+// @Description 导出全部订阅（包含标签、cron与过滤配置）为可在实例间迁移的文件
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} sub.Export "导出文件"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/sub/export [get]
+func exportSub(c *gin.Context) {
+	subList, err := op.GetSubList(c.Request.Context())
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	data, err := json.MarshalIndent(subio.Export(subList), "", "  ")
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=bestsub-subs-%s.json", time.Now().Format("20060102150405")))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// importSub 导入订阅
+// @Summary 导入订阅
+// @Description 从纯文本(每行一个链接)、OPML、Clash配置(proxy-providers)或BestSub导出文件批量导入订阅，已存在的链接会被跳过
+// @Tags 订阅
+// @Accept plain
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "导入格式 auto|text|opml|clash|bestsub，默认auto"
+// @Param cron_expr query string false "缺少cron表达式时使用的默认值" default(0 */6 * * *)
+// @Param tags query string false "缺少标签时使用的默认标签，逗号分隔"
+// @Param enable query bool false "缺少cron表达式的订阅是否启用" default(true)
+// @Param request body string true "导入内容"
+// @Success 200 {object} resp.ResponseStruct{data=sub.ImportResult} "导入成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/sub/import [post]
+func importSub(c *gin.Context) {
+	content, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	defaults := subio.Defaults{
+		CronExpr: c.DefaultQuery("cron_expr", "0 */6 * * *"),
+		Enable:   c.DefaultQuery("enable", "true") == "true",
+		Timeout:  10,
+	}
+	if tags := c.Query("tags"); tags != "" {
+		defaults.Tags = strings.Split(tags, ",")
+	}
+	reqs, err := subio.Parse(content, c.DefaultQuery("format", subio.FormatAuto), defaults)
+	if err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	subList, err := op.GetSubList(c.Request.Context())
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	existing := make(map[string]struct{}, len(subList))
+	for _, s := range subList {
+		var config sub.Config
+		json.Unmarshal([]byte(s.Config), &config)
+		existing[config.Url] = struct{}{}
+	}
+
+	result := sub.ImportResult{
+		Created: make([]sub.Response, 0),
+		Skipped: make([]string, 0),
+	}
+	subs := make([]*sub.Data, 0, len(reqs))
+	for _, req := range reqs {
+		if _, ok := existing[req.Config.Url]; ok {
+			result.Skipped = append(result.Skipped, req.Config.Url)
+			continue
+		}
+		if err := req.Config.Validate(); err != nil {
+			resp.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		existing[req.Config.Url] = struct{}{}
+		subData := req.GenData(0)
+		subs = append(subs, &subData)
+	}
+
+	if len(subs) > 0 {
+		if err := op.BatchCreateSub(c.Request.Context(), subs); err != nil {
+			log.Errorf("failed to import subs: %v", err)
+			resp.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	for _, subData := range subs {
+		cron.FetchAdd(subData)
+		result.Created = append(result.Created, subData.GenResponse(cron.FetchStatus(subData.ID), node.GetSubInfo(subData.ID)))
+	}
+	resp.Success(c, result)
+}
+
+// getSubRawContent 获取经节点池下载的订阅原始内容
+// @Summary 获取订阅原始内容
+// @Description 供 subconverter 读取通过节点池下载的订阅内容，token 仅在本次获取期间有效
+// @Tags 订阅
+// @Produce plain
+// @Param token path string true "一次性token"
+// @Success 200 {string} string "订阅原始内容"
+// @Failure 404 {object} resp.ResponseStruct "内容不存在"
+// @Router /api/v1/sub/raw/{token} [get]
+func getSubRawContent(c *gin.Context) {
+	content, ok := fetch.GetRawContent(c.Param("token"))
+	if !ok {
+		resp.Error(c, http.StatusNotFound, "content not found")
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", content)
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	shareModel "github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/modules/share"
+	"github.com/bestruirui/bestsub/internal/server/middleware"
+	"github.com/bestruirui/bestsub/internal/server/resp"
+	"github.com/bestruirui/bestsub/internal/server/router"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	router.NewGroupRouter("/api/v1/template").
+		Use(middleware.Auth()).
+		AddRoute(
+			router.NewRoute("", router.POST).
+				Handle(createSubTemplate),
+		).
+		AddRoute(
+			router.NewRoute("", router.GET).
+				Handle(getSubTemplates),
+		).
+		AddRoute(
+			router.NewRoute("/:id", router.GET).
+				Handle(getSubTemplate),
+		).
+		AddRoute(
+			router.NewRoute("/:id", router.PUT).
+				Handle(updateSubTemplate),
+		).
+		AddRoute(
+			router.NewRoute("/:id", router.DELETE).
+				Handle(deleteSubTemplate),
+		).
+		AddRoute(
+			router.NewRoute("/:id/versions", router.GET).
+				Handle(getSubTemplateVersions),
+		).
+		AddRoute(
+			router.NewRoute("/:id/rollback/:version", router.POST).
+				Handle(rollbackSubTemplate),
+		)
+}
+
+// @Summary 创建规则模板
+// @Description 创建规则模板，模板使用 Go template 语法，可用 .All .Countries .Subs (.Fastest n) 及 json names concat clashGroups singboxGroups 函数
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param data body shareModel.TemplateRequest true "模板数据"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.Template} "创建成功"
+// @Failure 400 {object} resp.ResponseStruct "模板无效"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template [post]
+func createSubTemplate(c *gin.Context) {
+	var req shareModel.TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Errorf("createSubTemplate: %v", err)
+		resp.ErrorBadRequest(c)
+		return
+	}
+	data := req.GenData()
+	if err := share.ValidateTemplate(&data); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := op.CreateTemplate(c.Request.Context(), &data); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, data)
+}
+
+// @Summary 获取规则模板列表
+// @Description 获取规则模板列表
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=[]shareModel.Template} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template [get]
+func getSubTemplates(c *gin.Context) {
+	templates, err := op.GetTemplateList(c.Request.Context())
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, templates)
+}
+
+// @Summary 获取规则模板
+// @Description 获取规则模板
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "模板ID"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.Template} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template/{id} [get]
+func getSubTemplate(c *gin.Context) {
+	idUint, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	t, err := op.GetTemplateByID(c.Request.Context(), uint16(idUint))
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, t)
+}
+
+// @Summary 更新规则模板
+// @Description 更新规则模板，旧内容保存为历史版本
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "模板ID"
+// @Param data body shareModel.TemplateRequest true "模板数据"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.Template} "更新成功"
+// @Failure 400 {object} resp.ResponseStruct "模板无效"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 409 {object} resp.ResponseStruct "模板正在被分享使用，不能修改类型"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template/{id} [put]
+func updateSubTemplate(c *gin.Context) {
+	var req shareModel.TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	idUint, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	data := req.GenData()
+	data.ID = uint16(idUint)
+	if err := share.ValidateTemplate(&data); err != nil {
+		resp.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if old, err := op.GetTemplateByID(c.Request.Context(), data.ID); err == nil && old.Type != data.Type {
+		if !checkTemplateUnused(c, data.ID, "its type cannot be changed") {
+			return
+		}
+	}
+	if err := op.UpdateTemplate(c.Request.Context(), &data); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, data)
+}
+
+// @Summary 删除规则模板
+// @Description 删除规则模板及其历史版本
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "模板ID"
+// @Success 200 {object} resp.ResponseStruct "删除成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 409 {object} resp.ResponseStruct "模板正在被分享使用"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template/{id} [delete]
+func deleteSubTemplate(c *gin.Context) {
+	idUint, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if !checkTemplateUnused(c, uint16(idUint), "cannot be deleted") {
+		return
+	}
+	if err := op.DeleteTemplate(c.Request.Context(), uint16(idUint)); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// @Summary 获取规则模板历史版本
+// @Description 获取规则模板历史版本，按版本号倒序
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "模板ID"
+// @Success 200 {object} resp.ResponseStruct{data=[]shareModel.TemplateVersion} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template/{id}/versions [get]
+func getSubTemplateVersions(c *gin.Context) {
+	idUint, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	versions, err := op.GetTemplateVersions(c.Request.Context(), uint16(idUint))
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, versions)
+}
+
+// @Summary 回滚规则模板
+// @Description 将模板内容恢复到指定历史版本
+// @Tags 模板
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "模板ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} resp.ResponseStruct{data=shareModel.Template} "回滚成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/template/{id}/rollback/{version} [post]
+func rollbackSubTemplate(c *gin.Context) {
+	idUint, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	version, err := strconv.ParseUint(c.Param("version"), 10, 32)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	t, err := op.RollbackTemplate(c.Request.Context(), uint16(idUint), uint32(version))
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, t)
+}
+
+// checkTemplateUnused 模板被分享引用时返回 409
+func checkTemplateUnused(c *gin.Context, id uint16, action string) bool {
+	users, err := share.TemplateUsers(c.Request.Context(), id)
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	if len(users) > 0 {
+		resp.Error(c, http.StatusConflict, fmt.Sprintf("template is used by share %s and %s", strings.Join(users, ", "), action))
+		return false
+	}
+	return true
+}
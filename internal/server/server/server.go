@@ -125,6 +125,9 @@ func IsInitialized() bool {
 func setRouter() (*gin.Engine, error) {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
+	if err := r.SetTrustedProxies(config.Base().Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("failed to set trusted proxies: %w", err)
+	}
 
 	// r.Use(middleware.Logging())
 	r.Use(middleware.Recovery())
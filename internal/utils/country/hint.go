@@ -0,0 +1,135 @@
+package country
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// nameAliases 节点名称中常见的地区别名
+var nameAliases = map[string]string{
+	"臺灣":             "TW",
+	"狮城":             "SG",
+	"HONG KONG":      "HK",
+	"HONGKONG":       "HK",
+	"TAIWAN":         "TW",
+	"MACAU":          "MO",
+	"JAPAN":          "JP",
+	"SINGAPORE":      "SG",
+	"KOREA":          "KR",
+	"UNITED STATES":  "US",
+	"AMERICA":        "US",
+	"UNITED KINGDOM": "GB",
+	"GERMANY":        "DE",
+	"FRANCE":         "FR",
+	"RUSSIA":         "RU",
+	"CANADA":         "CA",
+	"AUSTRALIA":      "AU",
+	"INDIA":          "IN",
+	"TURKEY":         "TR",
+	"NETHERLANDS":    "NL",
+	"UK":             "GB",
+}
+
+// hintKeys 按长度从长到短排列的中文名称与别名，优先匹配更具体的名称
+var hintKeys = func() []string {
+	keys := make([]string, 0, len(namesByNumeric)+len(nameAliases))
+	for code, name := range namesByNumeric {
+		keys = append(keys, name)
+		if short, ok := strings.CutPrefix(name, "中国"); ok && short != "" {
+			nameAliases[short] = code
+		}
+	}
+	for alias := range nameAliases {
+		keys = append(keys, alias)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}()
+
+var codeByName = func() map[string]string {
+	m := make(map[string]string, len(namesByNumeric))
+	for code, name := range namesByNumeric {
+		m[name] = code
+	}
+	return m
+}()
+
+// Hint 从节点名称中推断地区代码，依次匹配旗帜表情、中文名称与英文别名、两位地区代码
+func Hint(name string) string {
+	if code := flagCode(name); code != "" {
+		return code
+	}
+	upper := strings.ToUpper(name)
+	for _, key := range hintKeys {
+		if !strings.Contains(upper, key) {
+			continue
+		}
+		if code, ok := codeByName[key]; ok {
+			return code
+		}
+		if code := nameAliases[key]; code != "" {
+			if isASCII(key) && !wordMatch(upper, key) {
+				continue
+			}
+			return code
+		}
+	}
+	for _, word := range strings.FieldsFunc(upper, func(r rune) bool { return r > unicode.MaxASCII || !unicode.IsLetter(r) }) {
+		if len(word) != 2 {
+			continue
+		}
+		if _, ok := namesByNumeric[word]; ok {
+			return word
+		}
+	}
+	return ""
+}
+
+func flagCode(name string) string {
+	runes := []rune(name)
+	for i := 0; i+1 < len(runes); i++ {
+		r0, r1 := runes[i], runes[i+1]
+		if r0 >= 0x1F1E6 && r0 <= 0x1F1FF && r1 >= 0x1F1E6 && r1 <= 0x1F1FF {
+			code := string([]rune{'A' + r0 - 0x1F1E6, 'A' + r1 - 0x1F1E6})
+			if _, ok := namesByNumeric[code]; ok {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// wordMatch 英文别名需要完整匹配单词，避免 UK 匹配到 UKRAINE 之类的名称
+func wordMatch(s, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(s[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		if (i == 0 || !isLetter(s[i-1])) && (end == len(s) || !isLetter(s[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isLetter(b byte) bool {
+	return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z'
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}